@@ -1,3 +1,7 @@
 module github.com/VictoriaMetrics/victoriametrics-cloud-api-go
 
 go 1.26
+
+// go.yaml.in/yaml/v3 is the only third-party dependency of the client: rule files are YAML documents,
+// and it is required for their structured model, validation, diffs and conversions.
+require go.yaml.in/yaml/v3 v3.0.4
@@ -0,0 +1,273 @@
+package v1
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAccessTokenInventoryConcurrency - default number of deployments processed concurrently by AccessTokenInventory
+	DefaultAccessTokenInventoryConcurrency = 4
+	// DefaultAccessTokenMaxAge - default age after which access tokens are flagged as old
+	DefaultAccessTokenMaxAge = 90 * 24 * time.Hour
+)
+
+// AccessTokenRisk - risk flag of the access token in the inventory
+type AccessTokenRisk string
+
+const (
+	// AccessTokenRiskReadWrite - the access token allows both reading and writing data
+	AccessTokenRiskReadWrite AccessTokenRisk = "read-write"
+	// AccessTokenRiskNeverUsed - the access token has no recorded usage within AccessTokenUsageWindow
+	AccessTokenRiskNeverUsed AccessTokenRisk = "never-used"
+	// AccessTokenRiskOld - the access token is older than the configured maximum age
+	AccessTokenRiskOld AccessTokenRisk = "old"
+)
+
+func (r AccessTokenRisk) String() string {
+	return string(r)
+}
+
+// AccessTokenInventoryOptions - options for AccessTokenInventory
+type AccessTokenInventoryOptions struct {
+	// Concurrency - maximum number of deployments processed concurrently, DefaultAccessTokenInventoryConcurrency is used if zero
+	Concurrency int
+	// MaxAge - age after which access tokens are flagged as old, DefaultAccessTokenMaxAge is used if zero
+	MaxAge time.Duration
+}
+
+// AccessTokenInventoryItem - access token in the inventory. It never contains the secret of the token.
+type AccessTokenInventoryItem struct {
+	// DeploymentID - identifier of the deployment
+	DeploymentID string `json:"deployment_id"`
+	// DeploymentName - name of the deployment
+	DeploymentName string `json:"deployment_name"`
+	// ID is the unique identifier of the access token
+	ID string `json:"id"`
+	// Description is the human-readable description of the access token
+	Description string `json:"description"`
+	// Type is the access mode of the token (read-only, write-only, read+write)
+	Type AccessMode `json:"type"`
+	// TenantID represents the unique identifier of the tenant associated with this access token (optional)
+	TenantID string `json:"tenant_id,omitempty"`
+	// CreatedBy is the user who created the access token
+	CreatedBy string `json:"created_by"`
+	// CreatedAt is the timestamp of the access token creation
+	CreatedAt time.Time `json:"created_at"`
+	// AgeDays - number of full days since the access token creation
+	AgeDays int `json:"age_days"`
+	// LastUsedAt - timestamp of the last usage of the access token within AccessTokenUsageWindow
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// Risks - risk flags of the access token
+	Risks []AccessTokenRisk `json:"risks"`
+}
+
+// AccessTokenInventory - access tokens of all deployments of the account
+type AccessTokenInventory struct {
+	// GeneratedAt - time of the inventory generation
+	GeneratedAt time.Time `json:"generated_at"`
+	// MaxAgeDays - age in days after which access tokens are flagged as old
+	MaxAgeDays int `json:"max_age_days"`
+	// Tokens - access tokens sorted by deployment name, description and token ID
+	Tokens []AccessTokenInventoryItem `json:"tokens"`
+}
+
+// Flagged returns access tokens with at least one risk flag
+func (inv AccessTokenInventory) Flagged() []AccessTokenInventoryItem {
+	var flagged []AccessTokenInventoryItem
+	for _, item := range inv.Tokens {
+		if len(item.Risks) > 0 {
+			flagged = append(flagged, item)
+		}
+	}
+	return flagged
+}
+
+// AccessTokenInventory lists access tokens of all deployments of the account, processing up to opts.Concurrency
+// deployments at once, and flags risky tokens. Secrets of access tokens are never included.
+// If some deployments fail, the inventory contains tokens of the others and the error joins all failures.
+func (a *VMCloudAPIClient) AccessTokenInventory(ctx context.Context, opts AccessTokenInventoryOptions) (AccessTokenInventory, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultAccessTokenInventoryConcurrency
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultAccessTokenMaxAge
+	}
+	inv := AccessTokenInventory{
+		GeneratedAt: time.Now().UTC(),
+		MaxAgeDays:  int(opts.MaxAge / (24 * time.Hour)),
+		Tokens:      []AccessTokenInventoryItem{},
+	}
+	deployments, err := a.ListDeployments(ctx)
+	if err != nil {
+		return inv, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	items := make([][]AccessTokenInventoryItem, len(deployments))
+	errs := make([]error, len(deployments))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, d := range deployments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("failed to list access tokens of deployment %q: %w", d.ID, ctx.Err())
+				return
+			}
+			defer func() {
+				<-sem
+			}()
+			tokens, err := a.ListDeploymentAccessTokens(ctx, d.ID)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to list access tokens of deployment %q: %w", d.ID, err)
+				return
+			}
+			for _, token := range tokens {
+				items[i] = append(items[i], newAccessTokenInventoryItem(d, token, inv.GeneratedAt, opts.MaxAge))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, deploymentItems := range items {
+		inv.Tokens = append(inv.Tokens, deploymentItems...)
+	}
+	sort.SliceStable(inv.Tokens, func(i, j int) bool {
+		x, y := inv.Tokens[i], inv.Tokens[j]
+		if x.DeploymentName != y.DeploymentName {
+			return x.DeploymentName < y.DeploymentName
+		}
+		if x.Description != y.Description {
+			return x.Description < y.Description
+		}
+		return x.ID < y.ID
+	})
+	return inv, errors.Join(errs...)
+}
+
+func newAccessTokenInventoryItem(d DeploymentSummary, token AccessToken, now time.Time, maxAge time.Duration) AccessTokenInventoryItem {
+	item := AccessTokenInventoryItem{
+		DeploymentID:   d.ID,
+		DeploymentName: d.Name,
+		ID:             token.ID,
+		Description:    token.Description,
+		Type:           token.Type,
+		TenantID:       token.TenantID,
+		CreatedBy:      token.CreatedBy,
+		CreatedAt:      token.CreatedAt,
+		AgeDays:        int(now.Sub(token.CreatedAt) / (24 * time.Hour)),
+		LastUsedAt:     token.LastUsedAt,
+		Risks:          []AccessTokenRisk{},
+	}
+	if token.Type == AccessModeReadWrite {
+		item.Risks = append(item.Risks, AccessTokenRiskReadWrite)
+	}
+	if token.LastUsedAt == nil {
+		item.Risks = append(item.Risks, AccessTokenRiskNeverUsed)
+	}
+	if now.Sub(token.CreatedAt) >= maxAge {
+		item.Risks = append(item.Risks, AccessTokenRiskOld)
+	}
+	return item
+}
+
+var accessTokenInventoryColumns = []string{
+	"deployment_id", "deployment_name", "token_id", "description", "type", "tenant_id",
+	"created_by", "created_at", "age_days", "last_used_at", "risks",
+}
+
+func (item AccessTokenInventoryItem) columns() []string {
+	lastUsedAt := ""
+	if item.LastUsedAt != nil {
+		lastUsedAt = item.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	risks := make([]string, 0, len(item.Risks))
+	for _, r := range item.Risks {
+		risks = append(risks, r.String())
+	}
+	return []string{
+		item.DeploymentID, item.DeploymentName, item.ID, item.Description, item.Type.String(), item.TenantID,
+		item.CreatedBy, item.CreatedAt.UTC().Format(time.RFC3339), strconv.Itoa(item.AgeDays), lastUsedAt, strings.Join(risks, ";"),
+	}
+}
+
+// WriteCSV writes the inventory as CSV with a header row. Risk flags are separated by semicolons.
+// Cells starting with formula characters are prefixed with a single quote, so spreadsheets don't evaluate them.
+func (inv AccessTokenInventory) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accessTokenInventoryColumns); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, item := range inv.Tokens {
+		row := item.columns()
+		for i, cell := range row {
+			if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+				row[i] = "'" + cell
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the inventory as indented JSON
+func (inv AccessTokenInventory) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(inv); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// WriteMarkdown writes the inventory as a Markdown document with a summary and a table of access tokens
+func (inv AccessTokenInventory) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# Access token inventory\n\n")
+	fmt.Fprintf(&sb, "Generated at %s. %d tokens, %d flagged (tokens older than %d days are flagged as old).\n\n",
+		inv.GeneratedAt.UTC().Format(time.RFC3339), len(inv.Tokens), len(inv.Flagged()), inv.MaxAgeDays)
+	sb.WriteString("| Deployment | Token ID | Description | Type | Tenant | Created by | Created at | Age (days) | Last used at | Risks |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, item := range inv.Tokens {
+		row := item.columns()
+		// the deployment is identified by its name, the ID is used only if the name is empty
+		deployment := row[1]
+		if deployment == "" {
+			deployment = row[0]
+		}
+		cells := append([]string{deployment}, row[2:]...)
+		cells[len(cells)-1] = strings.ReplaceAll(cells[len(cells)-1], ";", ", ")
+		for i, cell := range cells {
+			cells[i] = escapeMarkdownCell(cell)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+	return nil
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
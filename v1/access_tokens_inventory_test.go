@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenInventory(t *testing.T) {
+	const (
+		prodID    = "123e4567-e89b-12d3-a456-426614174000"
+		stagingID = "223e4567-e89b-12d3-a456-426614174000"
+	)
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: prodID, Name: "prod"})
+	fake.addDeployment(DeploymentInfo{ID: stagingID, Name: "staging"})
+	fake.addToken(prodID, AccessToken{ID: "t1", Secret: "secret-value-1", Description: "vmagent", Type: AccessModeWrite, CreatedBy: "alice", CreatedAt: now.Add(-10 * 24 * time.Hour), LastUsedAt: &recent})
+	fake.addToken(prodID, AccessToken{ID: "t2", Secret: "secret-value-2", Description: "=cmd|admin", Type: AccessModeReadWrite, TenantID: "1:2", CreatedBy: "bob", CreatedAt: now.Add(-200 * 24 * time.Hour)})
+	fake.addToken(stagingID, AccessToken{ID: "t3", Secret: "secret-value-3", Description: "grafana", Type: AccessModeRead, CreatedBy: "alice", CreatedAt: now.Add(-time.Hour), LastUsedAt: &recent})
+
+	inv, err := client.AccessTokenInventory(context.Background(), AccessTokenInventoryOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("AccessTokenInventory() error = %v", err)
+	}
+	var ids []string
+	for _, item := range inv.Tokens {
+		ids = append(ids, item.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"t2", "t1", "t3"}) {
+		t.Errorf("AccessTokenInventory() token order = %v", ids)
+	}
+	wantRisks := []AccessTokenRisk{AccessTokenRiskReadWrite, AccessTokenRiskNeverUsed, AccessTokenRiskOld}
+	if !reflect.DeepEqual(inv.Tokens[0].Risks, wantRisks) || inv.Tokens[0].AgeDays != 200 || inv.Tokens[0].DeploymentName != "prod" {
+		t.Errorf("AccessTokenInventory() first token = %+v", inv.Tokens[0])
+	}
+	if flagged := inv.Flagged(); len(flagged) != 1 || flagged[0].ID != "t2" {
+		t.Errorf("Flagged() = %+v", flagged)
+	}
+
+	var csvBuf, jsonBuf, mdBuf bytes.Buffer
+	if err := inv.WriteCSV(&csvBuf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	if err := inv.WriteJSON(&jsonBuf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if err := inv.WriteMarkdown(&mdBuf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for name, out := range map[string]string{"CSV": csvBuf.String(), "JSON": jsonBuf.String(), "Markdown": mdBuf.String()} {
+		if strings.Contains(out, "secret") {
+			t.Errorf("%s output contains the token secret:\n%s", name, out)
+		}
+	}
+
+	records, err := csv.NewReader(&csvBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	if len(records) != 4 || !reflect.DeepEqual(records[0], accessTokenInventoryColumns) {
+		t.Fatalf("WriteCSV() records = %v", records)
+	}
+	if records[1][3] != "'=cmd|admin" || records[1][10] != "read-write;never-used;old" || records[1][9] != "" {
+		t.Errorf("WriteCSV() first row = %v", records[1])
+	}
+
+	var decoded AccessTokenInventory
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if len(decoded.Tokens) != 3 || decoded.Tokens[2].LastUsedAt == nil || decoded.MaxAgeDays != 90 {
+		t.Errorf("WriteJSON() decoded = %+v", decoded)
+	}
+
+	md := mdBuf.String()
+	if !strings.Contains(md, "3 tokens, 1 flagged (tokens older than 90 days are flagged as old)") ||
+		!strings.Contains(md, `| prod | t2 | =cmd\|admin | rw | 1:2 | bob |`) ||
+		!strings.Contains(md, "| read-write, never-used, old |") {
+		t.Errorf("WriteMarkdown() =\n%s", md)
+	}
+
+	fake.failOn(http.MethodGet, "/api/v1/deployments/"+stagingID+"/access_tokens", http.StatusInternalServerError)
+	inv, err = client.AccessTokenInventory(context.Background(), AccessTokenInventoryOptions{})
+	if err == nil || !strings.Contains(err.Error(), stagingID) {
+		t.Errorf("AccessTokenInventory() error = %v, want error for deployment %s", err, stagingID)
+	}
+	if len(inv.Tokens) != 2 {
+		t.Errorf("AccessTokenInventory() with failed deployment returned %d tokens, want 2", len(inv.Tokens))
+	}
+}
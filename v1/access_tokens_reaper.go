@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultAccessTokenUnusedAfter - default time without usage after which the access token is considered unused
+	DefaultAccessTokenUnusedAfter = 3 * 24 * time.Hour
+	// AccessTokenUsageWindow - period in which the API tracks LastUsedAt of access tokens
+	AccessTokenUsageWindow = 7 * 24 * time.Hour
+)
+
+// AccessTokenUsage - usage class of the access token
+type AccessTokenUsage string
+
+const (
+	// AccessTokenNeverUsed - the access token has no recorded usage within AccessTokenUsageWindow
+	AccessTokenNeverUsed AccessTokenUsage = "never-used"
+	// AccessTokenUnused - the access token hasn't been used for the configured duration
+	AccessTokenUnused AccessTokenUsage = "unused"
+	// AccessTokenActive - the access token has been used recently
+	AccessTokenActive AccessTokenUsage = "active"
+)
+
+func (u AccessTokenUsage) String() string {
+	return string(u)
+}
+
+// ClassifyAccessToken returns the usage class of the access token at the given time.
+// Tokens without LastUsedAt have no recorded usage within AccessTokenUsageWindow and are never-used,
+// tokens not used for unusedAfter are unused. The API tracks LastUsedAt only within AccessTokenUsageWindow,
+// so unusedAfter must be shorter than that, otherwise no token can be classified as unused.
+func ClassifyAccessToken(token AccessToken, now time.Time, unusedAfter time.Duration) AccessTokenUsage {
+	switch {
+	case token.LastUsedAt == nil:
+		return AccessTokenNeverUsed
+	case now.Sub(*token.LastUsedAt) >= unusedAfter:
+		return AccessTokenUnused
+	default:
+		return AccessTokenActive
+	}
+}
+
+// ReapAccessTokensOptions - options for ReapAccessTokens
+type ReapAccessTokensOptions struct {
+	// DeploymentIDs - deployments to scan, all deployments of the account are scanned if empty
+	DeploymentIDs []string
+	// UnusedAfter - time without usage after which the access token is unused, DefaultAccessTokenUnusedAfter is used if zero.
+	// It must be shorter than AccessTokenUsageWindow.
+	UnusedAfter time.Duration
+	// DeleteNeverUsedAfter enables deletion of never-used access tokens created earlier than the given duration ago.
+	// Never-used tokens are only reported if zero.
+	DeleteNeverUsedAfter time.Duration
+	// DeleteUnusedAfter enables deletion of unused access tokens created earlier than the given duration ago.
+	// Unused tokens are only reported if zero.
+	DeleteUnusedAfter time.Duration
+	// KeepDescriptions - regular expressions matched against the whole description of access tokens that are never deleted
+	KeepDescriptions []string
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+}
+
+// ReapedAccessToken - result of ReapAccessTokens for a single access token
+type ReapedAccessToken struct {
+	// DeploymentID - identifier of the deployment
+	DeploymentID string `json:"deployment_id"`
+	// DeploymentName - name of the deployment
+	DeploymentName string `json:"deployment_name"`
+	// ID is the unique identifier of the access token
+	ID string `json:"id"`
+	// Description is the human-readable description of the access token
+	Description string `json:"description"`
+	// Type is the access mode of the token (read-only, write-only, read+write)
+	Type AccessMode `json:"type"`
+	// TenantID represents the unique identifier of the tenant associated with this access token (optional)
+	TenantID string `json:"tenant_id,omitempty"`
+	// CreatedAt is the timestamp of the access token creation
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt - timestamp of the last usage of the access token within AccessTokenUsageWindow
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// Usage - usage class of the access token
+	Usage AccessTokenUsage `json:"usage"`
+	// Action - AccessTokenActionDeleted or AccessTokenActionUnchanged
+	Action AccessTokenAction `json:"action"`
+	// Reason - explanation of the action
+	Reason string `json:"reason"`
+}
+
+// ReapAccessTokensReport - result of ReapAccessTokens
+type ReapAccessTokensReport struct {
+	// DryRun is true if no changes have been made
+	DryRun bool `json:"dry_run"`
+	// CheckedAt - time used for classification of access tokens
+	CheckedAt time.Time `json:"checked_at"`
+	// Tokens - per-token results sorted by deployment name, description and token ID
+	Tokens []ReapedAccessToken `json:"tokens"`
+}
+
+// Count returns the number of access tokens of the given usage class
+func (r ReapAccessTokensReport) Count(usage AccessTokenUsage) int {
+	n := 0
+	for _, token := range r.Tokens {
+		if token.Usage == usage {
+			n++
+		}
+	}
+	return n
+}
+
+// ReapAccessTokens scans access tokens of deployments, classifies them by LastUsedAt and deletes
+// never-used and unused tokens older than thresholds of opts. Without thresholds only the report is produced.
+// On error, the returned report contains the tokens processed before the failure.
+func (a *VMCloudAPIClient) ReapAccessTokens(ctx context.Context, opts ReapAccessTokensOptions) (ReapAccessTokensReport, error) {
+	report := ReapAccessTokensReport{DryRun: opts.DryRun, CheckedAt: time.Now().UTC()}
+	if opts.UnusedAfter <= 0 {
+		opts.UnusedAfter = DefaultAccessTokenUnusedAfter
+	}
+	if opts.UnusedAfter >= AccessTokenUsageWindow {
+		return report, fmt.Errorf("unused access token threshold %s must be shorter than the usage tracking window %s", opts.UnusedAfter, AccessTokenUsageWindow)
+	}
+	keep := make([]*regexp.Regexp, 0, len(opts.KeepDescriptions))
+	for _, pattern := range opts.KeepDescriptions {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return report, fmt.Errorf("invalid access token description pattern %q: %w", pattern, err)
+		}
+		keep = append(keep, re)
+	}
+
+	deployments, err := a.reaperDeployments(ctx, opts.DeploymentIDs)
+	if err != nil {
+		return report, err
+	}
+	err = a.reapAccessTokens(ctx, deployments, opts, keep, &report)
+	sortReapedAccessTokens(report.Tokens)
+	return report, err
+}
+
+func (a *VMCloudAPIClient) reapAccessTokens(ctx context.Context, deployments DeploymentSummaryList, opts ReapAccessTokensOptions, keep []*regexp.Regexp, report *ReapAccessTokensReport) error {
+	for _, d := range deployments {
+		tokens, err := a.ListDeploymentAccessTokens(ctx, d.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list access tokens of deployment %q: %w", d.ID, err)
+		}
+		for _, token := range tokens {
+			result := ReapedAccessToken{
+				DeploymentID:   d.ID,
+				DeploymentName: d.Name,
+				ID:             token.ID,
+				Description:    token.Description,
+				Type:           token.Type,
+				TenantID:       token.TenantID,
+				CreatedAt:      token.CreatedAt,
+				LastUsedAt:     token.LastUsedAt,
+				Usage:          ClassifyAccessToken(token, report.CheckedAt, opts.UnusedAfter),
+			}
+			result.Action, result.Reason = reapAccessTokenAction(result, report.CheckedAt, opts, keep)
+			if result.Action == AccessTokenActionDeleted && !opts.DryRun {
+				if err := a.DeleteDeploymentAccessToken(ctx, d.ID, token.ID); err != nil {
+					return fmt.Errorf("failed to delete access token %q of deployment %q: %w", token.ID, d.ID, err)
+				}
+			}
+			report.Tokens = append(report.Tokens, result)
+		}
+	}
+	return nil
+}
+
+// reapAccessTokenAction decides whether the access token must be deleted and explains the decision
+func reapAccessTokenAction(token ReapedAccessToken, now time.Time, opts ReapAccessTokensOptions, keep []*regexp.Regexp) (AccessTokenAction, string) {
+	var threshold time.Duration
+	switch token.Usage {
+	case AccessTokenActive:
+		return AccessTokenActionUnchanged, fmt.Sprintf("used at %s", token.LastUsedAt.UTC().Format(time.RFC3339))
+	case AccessTokenNeverUsed:
+		threshold = opts.DeleteNeverUsedAfter
+	case AccessTokenUnused:
+		threshold = opts.DeleteUnusedAfter
+	}
+	for i, re := range keep {
+		if re.MatchString(token.Description) {
+			return AccessTokenActionUnchanged, fmt.Sprintf("description matches allow-list pattern %q", opts.KeepDescriptions[i])
+		}
+	}
+	if threshold <= 0 {
+		return AccessTokenActionUnchanged, fmt.Sprintf("deletion of %s tokens is disabled", token.Usage)
+	}
+	age := now.Sub(token.CreatedAt).Truncate(time.Second)
+	if age < threshold {
+		return AccessTokenActionUnchanged, fmt.Sprintf("%s token created %s ago, younger than %s", token.Usage, age, threshold)
+	}
+	return AccessTokenActionDeleted, fmt.Sprintf("%s token created %s ago, older than %s", token.Usage, age, threshold)
+}
+
+// reaperDeployments returns the deployments to scan
+func (a *VMCloudAPIClient) reaperDeployments(ctx context.Context, deploymentIDs []string) (DeploymentSummaryList, error) {
+	if len(deploymentIDs) == 0 {
+		deployments, err := a.ListDeployments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		return deployments, nil
+	}
+	deployments := make(DeploymentSummaryList, 0, len(deploymentIDs))
+	for _, id := range deploymentIDs {
+		info, err := a.GetDeploymentDetails(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %q: %w", id, err)
+		}
+		deployments = append(deployments, DeploymentSummary{ID: info.ID, Name: info.Name})
+	}
+	return deployments, nil
+}
+
+func sortReapedAccessTokens(tokens []ReapedAccessToken) {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].DeploymentName != tokens[j].DeploymentName {
+			return tokens[i].DeploymentName < tokens[j].DeploymentName
+		}
+		if tokens[i].Description != tokens[j].Description {
+			return tokens[i].Description < tokens[j].Description
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+}
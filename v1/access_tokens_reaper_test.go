@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClassifyAccessToken(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	old := now.Add(-4 * 24 * time.Hour)
+	tests := []struct {
+		name  string
+		token AccessToken
+		want  AccessTokenUsage
+	}{
+		{name: "never used", token: AccessToken{}, want: AccessTokenNeverUsed},
+		{name: "unused", token: AccessToken{LastUsedAt: &old}, want: AccessTokenUnused},
+		{name: "active", token: AccessToken{LastUsedAt: &recent}, want: AccessTokenActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAccessToken(tt.token, now, DefaultAccessTokenUnusedAfter); got != tt.want {
+				t.Errorf("ClassifyAccessToken() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReapAccessTokens(t *testing.T) {
+	const (
+		prodID    = "123e4567-e89b-12d3-a456-426614174000"
+		stagingID = "223e4567-e89b-12d3-a456-426614174000"
+	)
+	now := time.Now()
+	day := 24 * time.Hour
+	recent := now.Add(-time.Hour)
+	stale := now.Add(-5 * day)
+
+	newFleet := func(t *testing.T) (*fakeCloud, *VMCloudAPIClient) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: prodID, Name: "prod"})
+		fake.addDeployment(DeploymentInfo{ID: stagingID, Name: "staging"})
+		fake.addToken(prodID, AccessToken{ID: "active", Description: "vmagent", CreatedAt: now.Add(-30 * day), LastUsedAt: &recent})
+		fake.addToken(prodID, AccessToken{ID: "old-unused", Description: "grafana", CreatedAt: now.Add(-30 * day), LastUsedAt: &stale})
+		fake.addToken(prodID, AccessToken{ID: "break-glass", Description: "break-glass admin", CreatedAt: now.Add(-90 * day)})
+		fake.addToken(stagingID, AccessToken{ID: "old-never-used", Description: "ci", CreatedAt: now.Add(-30 * day)})
+		fake.addToken(stagingID, AccessToken{ID: "new-never-used", Description: "ci", CreatedAt: now.Add(-time.Hour)})
+		return fake, client
+	}
+	opts := ReapAccessTokensOptions{
+		DeleteNeverUsedAfter: 14 * day,
+		DeleteUnusedAfter:    14 * day,
+		KeepDescriptions:     []string{"break-glass.*"},
+	}
+
+	t.Run("report only", func(t *testing.T) {
+		fake, client := newFleet(t)
+		report, err := client.ReapAccessTokens(context.Background(), ReapAccessTokensOptions{})
+		if err != nil {
+			t.Fatalf("ReapAccessTokens() error = %v", err)
+		}
+		if len(report.Tokens) != 5 || report.Count(AccessTokenNeverUsed) != 3 || report.Count(AccessTokenUnused) != 1 || report.Count(AccessTokenActive) != 1 {
+			t.Errorf("ReapAccessTokens() = %+v", report)
+		}
+		for _, token := range report.Tokens {
+			if token.Action != AccessTokenActionUnchanged {
+				t.Errorf("ReapAccessTokens() without thresholds action for %s = %s", token.ID, token.Action)
+			}
+		}
+		if len(fake.tokenList(prodID))+len(fake.tokenList(stagingID)) != 5 {
+			t.Errorf("ReapAccessTokens() without thresholds deleted tokens")
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		fake, client := newFleet(t)
+		dryRunOpts := opts
+		dryRunOpts.DryRun = true
+		report, err := client.ReapAccessTokens(context.Background(), dryRunOpts)
+		if err != nil {
+			t.Fatalf("ReapAccessTokens() error = %v", err)
+		}
+		want := map[string]AccessTokenAction{
+			"break-glass":    AccessTokenActionUnchanged,
+			"old-unused":     AccessTokenActionDeleted,
+			"active":         AccessTokenActionUnchanged,
+			"new-never-used": AccessTokenActionUnchanged,
+			"old-never-used": AccessTokenActionDeleted,
+		}
+		var order []string
+		for _, token := range report.Tokens {
+			order = append(order, token.ID)
+			if token.Action != want[token.ID] {
+				t.Errorf("ReapAccessTokens() action for %s = %s (%s), want %s", token.ID, token.Action, token.Reason, want[token.ID])
+			}
+		}
+		if got := order[0] + "," + order[1] + "," + order[2]; got != "break-glass,old-unused,active" {
+			t.Errorf("ReapAccessTokens() order = %v", order)
+		}
+		if report.Tokens[0].Reason != `description matches allow-list pattern "break-glass.*"` {
+			t.Errorf("ReapAccessTokens() allow-listed reason = %q", report.Tokens[0].Reason)
+		}
+		if len(fake.tokenList(prodID))+len(fake.tokenList(stagingID)) != 5 {
+			t.Errorf("ReapAccessTokens() in dry-run mode deleted tokens")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fake, client := newFleet(t)
+		deleteOpts := opts
+		deleteOpts.DeploymentIDs = []string{stagingID}
+		report, err := client.ReapAccessTokens(context.Background(), deleteOpts)
+		if err != nil {
+			t.Fatalf("ReapAccessTokens() error = %v", err)
+		}
+		if len(report.Tokens) != 2 || report.Tokens[0].DeploymentName != "staging" {
+			t.Errorf("ReapAccessTokens() = %+v", report)
+		}
+		if tokens := fake.tokenList(stagingID); len(tokens) != 1 || tokens[0].ID != "new-never-used" {
+			t.Errorf("staging tokens after ReapAccessTokens() = %+v", tokens)
+		}
+		if len(fake.tokenList(prodID)) != 3 {
+			t.Errorf("ReapAccessTokens() modified tokens of the deployment that is not selected")
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, client := newFleet(t)
+		if _, err := client.ReapAccessTokens(context.Background(), ReapAccessTokensOptions{KeepDescriptions: []string{"("}}); err == nil {
+			t.Errorf("ReapAccessTokens() with invalid pattern error = nil, want error")
+		}
+	})
+
+	t.Run("unused threshold outside of usage window", func(t *testing.T) {
+		fake, client := newFleet(t)
+		opts := ReapAccessTokensOptions{UnusedAfter: AccessTokenUsageWindow, DeleteNeverUsedAfter: time.Hour}
+		if _, err := client.ReapAccessTokens(context.Background(), opts); err == nil {
+			t.Errorf("ReapAccessTokens() with UnusedAfter = AccessTokenUsageWindow error = nil, want error")
+		}
+		if got := len(fake.tokenList(stagingID)); got != 2 {
+			t.Errorf("ReapAccessTokens() modified tokens of the deployment: %d tokens, want 2", got)
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AccessTokenAction - action performed (or planned in dry-run mode) with the access token
+type AccessTokenAction string
+
+const (
+	// AccessTokenActionCreated - access token has been created
+	AccessTokenActionCreated AccessTokenAction = "created"
+	// AccessTokenActionDeleted - access token has been deleted
+	AccessTokenActionDeleted AccessTokenAction = "deleted"
+	// AccessTokenActionUnchanged - access token matches the desired state and has been left as is
+	AccessTokenActionUnchanged AccessTokenAction = "unchanged"
+	// AccessTokenActionUnmanaged - access token is not declared in the desired state and has been left as is
+	AccessTokenActionUnmanaged AccessTokenAction = "unmanaged"
+)
+
+func (a AccessTokenAction) String() string {
+	return string(a)
+}
+
+// AccessTokenResult - result of the operation with a single access token
+type AccessTokenResult struct {
+	// ID is the unique identifier of the access token (empty for tokens that would be created in dry-run mode)
+	ID string `json:"id,omitempty"`
+	// Description is the human-readable description of the access token
+	Description string `json:"description"`
+	// Type is the access mode of the token (read-only, write-only, read+write)
+	Type AccessMode `json:"type"`
+	// TenantID represents the unique identifier of the tenant associated with this access token (optional)
+	TenantID string `json:"tenant_id,omitempty"`
+	// Action - action performed with the access token
+	Action AccessTokenAction `json:"action"`
+}
+
+// AccessTokenSecretSink receives access tokens with revealed secrets right after their creation.
+type AccessTokenSecretSink func(ctx context.Context, token AccessToken) error
+
+// ReconcileAccessTokensOptions - options for ReconcileAccessTokens
+type ReconcileAccessTokensOptions struct {
+	// DeleteUnmanaged enables deletion of access tokens that are not declared in the desired state
+	DeleteUnmanaged bool
+	// ReplaceChangedType enables replacing access tokens that exist with a different access mode:
+	// a token with the desired access mode is created and the existing one is deleted
+	ReplaceChangedType bool
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+	// SecretSink receives created access tokens with revealed secrets (optional)
+	SecretSink AccessTokenSecretSink
+}
+
+// ReconcileAccessTokensReport - result of ReconcileAccessTokens
+type ReconcileAccessTokensReport struct {
+	// DryRun is true if no changes have been made
+	DryRun bool `json:"dry_run"`
+	// Tokens - per-token results sorted by description, tenant ID and token ID
+	Tokens []AccessTokenResult `json:"tokens"`
+}
+
+// AmbiguousAccessTokenError is returned when several access tokens share the same description and tenant,
+// so they cannot be matched with the desired state.
+type AmbiguousAccessTokenError struct {
+	// Description - description shared by the access tokens
+	Description string
+	// TenantID - tenant ID shared by the access tokens
+	TenantID string
+	// IDs - identifiers of the conflicting access tokens
+	IDs []string
+}
+
+func (e *AmbiguousAccessTokenError) Error() string {
+	return fmt.Sprintf("ambiguous access tokens with description %q and tenant ID %q: %s", e.Description, e.TenantID, strings.Join(e.IDs, ", "))
+}
+
+type accessTokenKey struct {
+	description string
+	tenantID    string
+}
+
+// ReconcileAccessTokens makes the set of access tokens of the deployment match the desired one.
+// Existing tokens are matched with the desired ones by description and tenant ID.
+// Missing tokens are created and passed with revealed secrets to opts.SecretSink.
+// Tokens that are not declared in desired are deleted only if opts.DeleteUnmanaged is set,
+// tokens with a different access mode are replaced only if opts.ReplaceChangedType is set.
+// Desired tokens are validated before any changes are made.
+// The deployment is not modified if the desired or existing tokens are ambiguous.
+func (a *VMCloudAPIClient) ReconcileAccessTokens(ctx context.Context, deploymentID string, desired []AccessTokenCreateRequest, opts ReconcileAccessTokensOptions) (ReconcileAccessTokensReport, error) {
+	report := ReconcileAccessTokensReport{DryRun: opts.DryRun}
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return report, err
+	}
+
+	desiredByKey := make(map[accessTokenKey]AccessTokenCreateRequest, len(desired))
+	for _, token := range desired {
+		if err := checkAccessTokenCreateRequest(token); err != nil {
+			return report, fmt.Errorf("invalid desired access token %q: %w", token.Description, err)
+		}
+		key := accessTokenKey{description: token.Description, tenantID: normalizeTenantID(token.TenantID)}
+		if _, ok := desiredByKey[key]; ok {
+			return report, fmt.Errorf("duplicate desired access token with description %q and tenant ID %q", token.Description, token.TenantID)
+		}
+		desiredByKey[key] = token
+	}
+
+	existing, err := a.ListDeploymentAccessTokens(ctx, deploymentID)
+	if err != nil {
+		return report, fmt.Errorf("failed to list access tokens of deployment %q: %w", deploymentID, err)
+	}
+	existingByKey := make(map[accessTokenKey][]AccessToken, len(existing))
+	for _, token := range existing {
+		key := accessTokenKey{description: token.Description, tenantID: normalizeTenantID(token.TenantID)}
+		existingByKey[key] = append(existingByKey[key], token)
+	}
+
+	// Plan all changes before applying any of them
+	var kept []AccessTokenResult
+	var toCreate []AccessTokenCreateRequest
+	var toDelete []AccessToken
+	for key, tokens := range existingByKey {
+		want, isDesired := desiredByKey[key]
+		if isDesired && len(tokens) > 1 {
+			ids := make([]string, 0, len(tokens))
+			for _, token := range tokens {
+				ids = append(ids, token.ID)
+			}
+			sort.Strings(ids)
+			return report, &AmbiguousAccessTokenError{Description: key.description, TenantID: key.tenantID, IDs: ids}
+		}
+		for _, token := range tokens {
+			switch {
+			case isDesired && token.Type == want.Type:
+				kept = append(kept, newAccessTokenResult(token, AccessTokenActionUnchanged))
+			case isDesired && !opts.ReplaceChangedType:
+				return report, fmt.Errorf("access token %q with description %q has type %s instead of %s and cannot be replaced without ReplaceChangedType option", token.ID, token.Description, token.Type, want.Type)
+			case isDesired:
+				toCreate = append(toCreate, want)
+				toDelete = append(toDelete, token)
+			case opts.DeleteUnmanaged:
+				toDelete = append(toDelete, token)
+			default:
+				kept = append(kept, newAccessTokenResult(token, AccessTokenActionUnmanaged))
+			}
+		}
+	}
+	for key, token := range desiredByKey {
+		if _, ok := existingByKey[key]; !ok {
+			toCreate = append(toCreate, token)
+		}
+	}
+	sort.Slice(toCreate, func(i, j int) bool {
+		if toCreate[i].Description != toCreate[j].Description {
+			return toCreate[i].Description < toCreate[j].Description
+		}
+		return toCreate[i].TenantID < toCreate[j].TenantID
+	})
+	sort.Slice(toDelete, func(i, j int) bool { return toDelete[i].ID < toDelete[j].ID })
+
+	// Tokens are created before deletion of the old ones, so clients can switch to the new tokens
+	report.Tokens = kept
+	err = a.applyAccessTokenChanges(ctx, deploymentID, toCreate, toDelete, opts, &report)
+	sortAccessTokenResults(report.Tokens)
+	return report, err
+}
+
+func (a *VMCloudAPIClient) applyAccessTokenChanges(ctx context.Context, deploymentID string, toCreate []AccessTokenCreateRequest, toDelete []AccessToken, opts ReconcileAccessTokensOptions, report *ReconcileAccessTokensReport) error {
+	for _, request := range toCreate {
+		if opts.DryRun {
+			report.Tokens = append(report.Tokens, AccessTokenResult{
+				Description: request.Description,
+				Type:        request.Type,
+				TenantID:    request.TenantID,
+				Action:      AccessTokenActionCreated,
+			})
+			continue
+		}
+		created, err := a.createAndRevealAccessToken(ctx, deploymentID, request, opts.SecretSink)
+		if created.ID != "" {
+			report.Tokens = append(report.Tokens, newAccessTokenResult(created, AccessTokenActionCreated))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, token := range toDelete {
+		if !opts.DryRun {
+			if err := a.DeleteDeploymentAccessToken(ctx, deploymentID, token.ID); err != nil {
+				return err
+			}
+		}
+		report.Tokens = append(report.Tokens, newAccessTokenResult(token, AccessTokenActionDeleted))
+	}
+	return nil
+}
+
+// createAndRevealAccessToken creates the access token and passes it with revealed secret to the sink.
+// The created token is returned even if revealing or the sink fails.
+func (a *VMCloudAPIClient) createAndRevealAccessToken(ctx context.Context, deploymentID string, request AccessTokenCreateRequest, sink AccessTokenSecretSink) (AccessToken, error) {
+	created, err := a.CreateDeploymentAccessToken(ctx, deploymentID, request)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("failed to create access token %q for deployment %q: %w", request.Description, deploymentID, err)
+	}
+	if sink == nil {
+		return created, nil
+	}
+	revealed, err := a.RevealDeploymentAccessToken(ctx, deploymentID, created.ID)
+	if err != nil {
+		return created, fmt.Errorf("failed to reveal access token %q for deployment %q: %w", created.ID, deploymentID, err)
+	}
+	if err := sink(ctx, revealed); err != nil {
+		return created, fmt.Errorf("failed to pass secret of access token %q to the sink: %w", created.ID, err)
+	}
+	return created, nil
+}
+
+func newAccessTokenResult(token AccessToken, action AccessTokenAction) AccessTokenResult {
+	return AccessTokenResult{
+		ID:          token.ID,
+		Description: token.Description,
+		Type:        token.Type,
+		TenantID:    token.TenantID,
+		Action:      action,
+	}
+}
+
+func sortAccessTokenResults(results []AccessTokenResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Description != results[j].Description {
+			return results[i].Description < results[j].Description
+		}
+		if results[i].TenantID != results[j].TenantID {
+			return results[i].TenantID < results[j].TenantID
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// normalizeTenantID returns tenant ID in <accountID>:<projectID> form, so "12" and "12:0" are considered equal.
+// Empty tenant ID means the default tenant 0:0. Invalid tenant IDs are returned as is.
+func normalizeTenantID(tenantID string) string {
+	if tenantID == "" {
+		return TenantID{}.String()
+	}
+	if t, err := ParseTenantID(tenantID); err == nil {
+		return t.String()
+	}
+	return tenantID
+}
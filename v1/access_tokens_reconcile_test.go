@@ -0,0 +1,207 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReconcileAccessTokens(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	desired := []AccessTokenCreateRequest{
+		{Description: "grafana-read", Type: AccessModeRead},
+		{Description: "vmagent-prod", Type: AccessModeWrite},
+	}
+
+	tests := []struct {
+		name       string
+		opts       ReconcileAccessTokensOptions
+		want       []AccessTokenResult
+		wantTokens int
+	}{
+		{
+			name: "dry run",
+			opts: ReconcileAccessTokensOptions{DryRun: true, DeleteUnmanaged: true},
+			want: []AccessTokenResult{
+				{ID: "existing-1", Description: "grafana-read", Type: AccessModeRead, Action: AccessTokenActionUnchanged},
+				{ID: "existing-2", Description: "manual", Type: AccessModeReadWrite, Action: AccessTokenActionDeleted},
+				{Description: "vmagent-prod", Type: AccessModeWrite, Action: AccessTokenActionCreated},
+			},
+			wantTokens: 2,
+		},
+		{
+			name: "keep unmanaged tokens",
+			opts: ReconcileAccessTokensOptions{},
+			want: []AccessTokenResult{
+				{ID: "existing-1", Description: "grafana-read", Type: AccessModeRead, Action: AccessTokenActionUnchanged},
+				{ID: "existing-2", Description: "manual", Type: AccessModeReadWrite, Action: AccessTokenActionUnmanaged},
+				{ID: "token-1", Description: "vmagent-prod", Type: AccessModeWrite, Action: AccessTokenActionCreated},
+			},
+			wantTokens: 3,
+		},
+		{
+			name: "delete unmanaged tokens",
+			opts: ReconcileAccessTokensOptions{DeleteUnmanaged: true},
+			want: []AccessTokenResult{
+				{ID: "existing-1", Description: "grafana-read", Type: AccessModeRead, Action: AccessTokenActionUnchanged},
+				{ID: "existing-2", Description: "manual", Type: AccessModeReadWrite, Action: AccessTokenActionDeleted},
+				{ID: "token-1", Description: "vmagent-prod", Type: AccessModeWrite, Action: AccessTokenActionCreated},
+			},
+			wantTokens: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeCloud(t)
+			fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+			fake.addToken(deploymentID, AccessToken{ID: "existing-1", Description: "grafana-read", Type: AccessModeRead})
+			fake.addToken(deploymentID, AccessToken{ID: "existing-2", Description: "manual", Type: AccessModeReadWrite})
+
+			var revealed []AccessToken
+			tt.opts.SecretSink = func(_ context.Context, token AccessToken) error {
+				revealed = append(revealed, token)
+				return nil
+			}
+			report, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, tt.opts)
+			if err != nil {
+				t.Fatalf("ReconcileAccessTokens() error = %v", err)
+			}
+			if len(report.Tokens) != len(tt.want) {
+				t.Fatalf("ReconcileAccessTokens() returned %d results, want %d: %+v", len(report.Tokens), len(tt.want), report.Tokens)
+			}
+			for i := range tt.want {
+				if report.Tokens[i] != tt.want[i] {
+					t.Errorf("ReconcileAccessTokens() result %d = %+v, want %+v", i, report.Tokens[i], tt.want[i])
+				}
+			}
+			if got := len(fake.tokenList(deploymentID)); got != tt.wantTokens {
+				t.Errorf("deployment has %d tokens after reconciliation, want %d", got, tt.wantTokens)
+			}
+			if tt.opts.DryRun {
+				if len(revealed) != 0 {
+					t.Errorf("ReconcileAccessTokens() revealed secrets in dry-run mode")
+				}
+				return
+			}
+			if len(revealed) != 1 || revealed[0].Secret != "secret-token-1" {
+				t.Errorf("ReconcileAccessTokens() passed %+v to the sink, want revealed vmagent-prod token", revealed)
+			}
+		})
+	}
+}
+
+func TestReconcileAccessTokens_Tenants(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test", Type: DeploymentTypeCluster})
+	fake.addToken(deploymentID, AccessToken{ID: "existing-1", Description: "tenant", Type: AccessModeReadWrite, TenantID: "12"})
+	fake.addToken(deploymentID, AccessToken{ID: "existing-2", Description: "tenant", Type: AccessModeReadWrite, TenantID: "13:0"})
+	fake.addToken(deploymentID, AccessToken{ID: "existing-3", Description: "default", Type: AccessModeRead, TenantID: "0:0"})
+
+	// the token without tenant belongs to the default tenant 0:0
+	desired := []AccessTokenCreateRequest{
+		{Description: "tenant", Type: AccessModeReadWrite, TenantID: "12:0"},
+		{Description: "default", Type: AccessModeRead},
+	}
+	report, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{})
+	if err != nil {
+		t.Fatalf("ReconcileAccessTokens() error = %v", err)
+	}
+	want := []AccessTokenResult{
+		{ID: "existing-3", Description: "default", Type: AccessModeRead, TenantID: "0:0", Action: AccessTokenActionUnchanged},
+		{ID: "existing-1", Description: "tenant", Type: AccessModeReadWrite, TenantID: "12", Action: AccessTokenActionUnchanged},
+		{ID: "existing-2", Description: "tenant", Type: AccessModeReadWrite, TenantID: "13:0", Action: AccessTokenActionUnmanaged},
+	}
+	if len(report.Tokens) != len(want) {
+		t.Fatalf("ReconcileAccessTokens() returned %d results, want %d: %+v", len(report.Tokens), len(want), report.Tokens)
+	}
+	for i := range want {
+		if report.Tokens[i] != want[i] {
+			t.Errorf("ReconcileAccessTokens() result %d = %+v, want %+v", i, report.Tokens[i], want[i])
+		}
+	}
+}
+
+func TestReconcileAccessTokens_Errors(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+
+	t.Run("ambiguous existing tokens", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+		fake.addToken(deploymentID, AccessToken{ID: "a", Description: "grafana", Type: AccessModeRead})
+		fake.addToken(deploymentID, AccessToken{ID: "b", Description: "grafana", Type: AccessModeRead})
+
+		desired := []AccessTokenCreateRequest{
+			{Description: "grafana", Type: AccessModeRead},
+			{Description: "vmagent", Type: AccessModeWrite},
+		}
+		_, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{DeleteUnmanaged: true})
+		var ambiguousErr *AmbiguousAccessTokenError
+		if !errors.As(err, &ambiguousErr) {
+			t.Fatalf("ReconcileAccessTokens() error = %v, want AmbiguousAccessTokenError", err)
+		}
+		if len(ambiguousErr.IDs) != 2 {
+			t.Errorf("AmbiguousAccessTokenError.IDs = %v, want [a b]", ambiguousErr.IDs)
+		}
+		if got := len(fake.tokenList(deploymentID)); got != 2 {
+			t.Errorf("deployment has been modified: %d tokens, want 2", got)
+		}
+	})
+
+	t.Run("duplicate desired tokens", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+		desired := []AccessTokenCreateRequest{
+			{Description: "grafana", Type: AccessModeRead},
+			{Description: "grafana", Type: AccessModeWrite},
+		}
+		if _, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{}); err == nil {
+			t.Fatalf("ReconcileAccessTokens() should return an error for duplicate desired tokens")
+		}
+	})
+
+	t.Run("invalid desired token", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+		fake.addToken(deploymentID, AccessToken{ID: "a", Description: "manual", Type: AccessModeRead})
+		desired := []AccessTokenCreateRequest{
+			{Description: "grafana", Type: AccessModeRead},
+			{Description: "vmagent", Type: AccessModeWrite, TenantID: "a:b"},
+			{Description: "vmalert", Type: "admin"},
+		}
+		if _, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{DeleteUnmanaged: true}); err == nil {
+			t.Fatalf("ReconcileAccessTokens() should return an error for invalid desired tokens")
+		}
+		tokens := fake.tokenList(deploymentID)
+		if len(tokens) != 1 || tokens[0].ID != "a" {
+			t.Errorf("deployment has been modified: %+v", tokens)
+		}
+	})
+
+	t.Run("type mismatch without replacement", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+		fake.addToken(deploymentID, AccessToken{ID: "a", Description: "grafana", Type: AccessModeReadWrite})
+		desired := []AccessTokenCreateRequest{{Description: "grafana", Type: AccessModeRead}}
+		if _, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{}); err == nil {
+			t.Fatalf("ReconcileAccessTokens() should return an error for token with different type")
+		}
+
+		if _, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{DeleteUnmanaged: true}); err == nil {
+			t.Fatalf("ReconcileAccessTokens() should not replace token with different type with DeleteUnmanaged option only")
+		}
+
+		report, err := client.ReconcileAccessTokens(context.Background(), deploymentID, desired, ReconcileAccessTokensOptions{ReplaceChangedType: true})
+		if err != nil {
+			t.Fatalf("ReconcileAccessTokens() error = %v", err)
+		}
+		tokens := fake.tokenList(deploymentID)
+		if len(tokens) != 1 || tokens[0].Type != AccessModeRead {
+			t.Errorf("token has not been replaced: %+v", tokens)
+		}
+		if len(report.Tokens) != 2 {
+			t.Errorf("ReconcileAccessTokens() returned %d results, want 2: %+v", len(report.Tokens), report.Tokens)
+		}
+	})
+}
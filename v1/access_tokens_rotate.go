@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAccessTokenRotationGracePeriod - default time between the replacement token creation and the old token deletion
+	DefaultAccessTokenRotationGracePeriod = time.Hour
+	// DefaultAccessTokenRotationPollInterval - default interval of checking LastUsedAt of the old token
+	DefaultAccessTokenRotationPollInterval = time.Minute
+)
+
+var accessTokenRotationSuffixRegex = regexp.MustCompile(`\s*\(rotated from [^()]*\)$`)
+
+// AccessTokenRotationTrigger - reason of the old access token deletion by RotateAccessToken
+type AccessTokenRotationTrigger string
+
+const (
+	// AccessTokenRotationGracePeriod - the grace period has elapsed
+	AccessTokenRotationGracePeriod AccessTokenRotationTrigger = "grace-period"
+	// AccessTokenRotationStale - the old token hasn't been used for RotateAccessTokenOptions.StaleAfter
+	AccessTokenRotationStale AccessTokenRotationTrigger = "stale"
+	// AccessTokenRotationAlreadyDeleted - the old token had been deleted before, e.g. by the interrupted rotation
+	AccessTokenRotationAlreadyDeleted AccessTokenRotationTrigger = "already-deleted"
+)
+
+func (t AccessTokenRotationTrigger) String() string {
+	return string(t)
+}
+
+// RotateAccessTokenOptions - options for RotateAccessToken
+type RotateAccessTokenOptions struct {
+	// Distribute receives the replacement token with revealed secret and must deliver it to all consumers of the old token.
+	// It is called again when the interrupted rotation is resumed, so it must be idempotent.
+	Distribute AccessTokenSecretSink
+	// GracePeriod - time since the replacement token creation after which the old token is deleted,
+	// DefaultAccessTokenRotationGracePeriod is used if zero. It is measured by the local clock
+	// and starts again when the interrupted rotation is resumed.
+	GracePeriod time.Duration
+	// StaleAfter enables earlier deletion of the old token if it hasn't been used for the given duration
+	// and at least the same time has passed since the replacement token creation.
+	// The API tracks LastUsedAt only within AccessTokenUsageWindow, so longer durations are not useful.
+	// The old token without LastUsedAt, i.e. with no recorded usage within the window, is considered stale
+	// as soon as StaleAfter has passed since the replacement token creation.
+	StaleAfter time.Duration
+	// PollInterval - interval of checking LastUsedAt of the old token, DefaultAccessTokenRotationPollInterval is used if zero
+	PollInterval time.Duration
+}
+
+// RotateAccessTokenResult - result of RotateAccessToken
+type RotateAccessTokenResult struct {
+	// OldToken - rotated access token without the secret, empty if it had been deleted before
+	OldToken AccessToken `json:"old_token"`
+	// NewToken - replacement access token without the secret
+	NewToken AccessToken `json:"new_token"`
+	// Resumed is true if the replacement token had been created by the interrupted rotation
+	Resumed bool `json:"resumed"`
+	// Trigger - reason of the old token deletion
+	Trigger AccessTokenRotationTrigger `json:"trigger"`
+}
+
+// AccessTokenRotationDescription returns the description of the replacement token for the token with the given description and ID.
+// The suffix added by the previous rotation is replaced, so descriptions don't grow with every rotation.
+func AccessTokenRotationDescription(description, tokenID string) string {
+	return accessTokenRotationSuffixRegex.ReplaceAllString(description, "") + " (rotated from " + tokenID + ")"
+}
+
+// RotateAccessToken replaces the access token of the deployment with a new one of the same type, tenant and description
+// with the rotation suffix (see AccessTokenRotationDescription). The replacement token with revealed secret is passed
+// to opts.Distribute. Then the old token is deleted after opts.GracePeriod since the replacement creation,
+// or earlier if it goes stale according to opts.StaleAfter.
+//
+// The rotation is resumable: the replacement token is found by its description, so calling RotateAccessToken
+// again with the same token ID after the interruption doesn't create another token.
+func (a *VMCloudAPIClient) RotateAccessToken(ctx context.Context, deploymentID, tokenID string, opts RotateAccessTokenOptions) (RotateAccessTokenResult, error) {
+	var result RotateAccessTokenResult
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return result, err
+	}
+	if err := checkPathParam("access token ID", tokenID); err != nil {
+		return result, err
+	}
+	if opts.Distribute == nil {
+		return result, fmt.Errorf("distribution callback must be set for rotation of access token %q", tokenID)
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultAccessTokenRotationGracePeriod
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultAccessTokenRotationPollInterval
+	}
+
+	tokens, err := a.ListDeploymentAccessTokens(ctx, deploymentID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list access tokens of deployment %q: %w", deploymentID, err)
+	}
+	oldToken, ok := findAccessToken(tokens, tokenID)
+	if !ok {
+		// the old token is deleted only after the distribution, so the rotation has been completed
+		for _, token := range tokens {
+			if strings.HasSuffix(token.Description, "(rotated from "+tokenID+")") {
+				token.Secret = ""
+				result.NewToken, result.Resumed, result.Trigger = token, true, AccessTokenRotationAlreadyDeleted
+				return result, nil
+			}
+		}
+		return result, fmt.Errorf("access token %q not found in deployment %q", tokenID, deploymentID)
+	}
+	result.OldToken = oldToken
+	result.OldToken.Secret = ""
+
+	request := AccessTokenCreateRequest{
+		Type:        oldToken.Type,
+		Description: AccessTokenRotationDescription(oldToken.Description, oldToken.ID),
+		TenantID:    oldToken.TenantID,
+	}
+	var candidates []AccessToken
+	for _, token := range tokens {
+		if token.Description == request.Description && normalizeTenantID(token.TenantID) == normalizeTenantID(request.TenantID) && token.Type == request.Type {
+			candidates = append(candidates, token)
+		}
+	}
+	// the local time is used instead of CreatedAt of the replacement token, so clock skew between
+	// the client and the API doesn't shift the deadlines
+	replacedAt := time.Now()
+	switch len(candidates) {
+	case 0:
+		result.NewToken, err = a.CreateDeploymentAccessToken(ctx, deploymentID, request)
+		if err != nil {
+			return result, fmt.Errorf("failed to create replacement for access token %q of deployment %q: %w", tokenID, deploymentID, err)
+		}
+	case 1:
+		result.NewToken, result.Resumed = candidates[0], true
+	default:
+		ids := make([]string, 0, len(candidates))
+		for _, token := range candidates {
+			ids = append(ids, token.ID)
+		}
+		return result, &AmbiguousAccessTokenError{Description: request.Description, TenantID: request.TenantID, IDs: ids}
+	}
+	result.NewToken.Secret = ""
+
+	revealed, err := a.RevealDeploymentAccessToken(ctx, deploymentID, result.NewToken.ID)
+	if err != nil {
+		return result, fmt.Errorf("failed to reveal access token %q for deployment %q: %w", result.NewToken.ID, deploymentID, err)
+	}
+	if err := opts.Distribute(ctx, revealed); err != nil {
+		return result, fmt.Errorf("failed to distribute secret of access token %q: %w", result.NewToken.ID, err)
+	}
+
+	result.Trigger, err = a.waitAccessTokenRotation(ctx, deploymentID, tokenID, replacedAt, opts)
+	if err != nil || result.Trigger == AccessTokenRotationAlreadyDeleted {
+		return result, err
+	}
+	if err := a.DeleteDeploymentAccessToken(ctx, deploymentID, tokenID); err != nil {
+		return result, fmt.Errorf("failed to delete rotated access token %q of deployment %q: %w", tokenID, deploymentID, err)
+	}
+	return result, nil
+}
+
+// waitAccessTokenRotation waits until the old token can be deleted and returns the reason
+func (a *VMCloudAPIClient) waitAccessTokenRotation(ctx context.Context, deploymentID, tokenID string, replacedAt time.Time, opts RotateAccessTokenOptions) (AccessTokenRotationTrigger, error) {
+	deadline := replacedAt.Add(opts.GracePeriod)
+	for {
+		now := time.Now()
+		if !now.Before(deadline) {
+			return AccessTokenRotationGracePeriod, nil
+		}
+		if opts.StaleAfter > 0 && now.Sub(replacedAt) >= opts.StaleAfter {
+			tokens, err := a.ListDeploymentAccessTokens(ctx, deploymentID)
+			if err != nil {
+				return "", fmt.Errorf("failed to list access tokens of deployment %q: %w", deploymentID, err)
+			}
+			oldToken, ok := findAccessToken(tokens, tokenID)
+			if !ok {
+				return AccessTokenRotationAlreadyDeleted, nil
+			}
+			if oldToken.LastUsedAt == nil || now.Sub(*oldToken.LastUsedAt) >= opts.StaleAfter {
+				return AccessTokenRotationStale, nil
+			}
+		}
+		wait := min(opts.PollInterval, time.Until(deadline))
+		if opts.StaleAfter > 0 {
+			if untilStale := time.Until(replacedAt.Add(opts.StaleAfter)); untilStale > 0 {
+				wait = min(wait, untilStale)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("rotation of access token %q has been interrupted, call RotateAccessToken again to resume: %w", tokenID, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+func findAccessToken(tokens AccessTokensList, tokenID string) (AccessToken, bool) {
+	for _, token := range tokens {
+		if token.ID == tokenID {
+			return token, true
+		}
+	}
+	return AccessToken{}, false
+}
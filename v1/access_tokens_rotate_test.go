@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenRotationDescription(t *testing.T) {
+	if got := AccessTokenRotationDescription("ci", "token-1"); got != "ci (rotated from token-1)" {
+		t.Errorf("AccessTokenRotationDescription() = %q", got)
+	}
+	if got := AccessTokenRotationDescription("ci (rotated from token-1)", "token-2"); got != "ci (rotated from token-2)" {
+		t.Errorf("AccessTokenRotationDescription() for rotated token = %q", got)
+	}
+}
+
+func TestRotateAccessToken(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	tokensPath := "/api/v1/deployments/" + deploymentID + "/access_tokens"
+	ctx := context.Background()
+
+	t.Run("grace period", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		recentlyUsed := time.Now()
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeWrite, LastUsedAt: &recentlyUsed})
+
+		var distributed []AccessToken
+		result, err := client.RotateAccessToken(ctx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute: func(_ context.Context, token AccessToken) error {
+				distributed = append(distributed, token)
+				return nil
+			},
+			GracePeriod:  30 * time.Millisecond,
+			StaleAfter:   time.Hour,
+			PollInterval: 5 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if result.Trigger != AccessTokenRotationGracePeriod || result.Resumed || result.OldToken.ID != old.ID {
+			t.Errorf("RotateAccessToken() = %+v", result)
+		}
+		if result.NewToken.Description != "ci (rotated from "+old.ID+")" || result.NewToken.Type != AccessModeWrite || result.NewToken.Secret != "" {
+			t.Errorf("RotateAccessToken() new token = %+v", result.NewToken)
+		}
+		if len(distributed) != 1 || distributed[0].ID != result.NewToken.ID || distributed[0].Secret == "" {
+			t.Errorf("distributed tokens = %+v", distributed)
+		}
+		if tokens := fake.tokenList(deploymentID); len(tokens) != 1 || tokens[0].ID != result.NewToken.ID {
+			t.Errorf("tokens after rotation = %+v", tokens)
+		}
+	})
+
+	t.Run("stale old token", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		lastUsed := time.Now().Add(-time.Hour)
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeRead, LastUsedAt: &lastUsed})
+
+		result, err := client.RotateAccessToken(ctx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute:   func(context.Context, AccessToken) error { return nil },
+			GracePeriod:  time.Hour,
+			StaleAfter:   10 * time.Millisecond,
+			PollInterval: 5 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if result.Trigger != AccessTokenRotationStale {
+			t.Errorf("RotateAccessToken() trigger = %s, want %s", result.Trigger, AccessTokenRotationStale)
+		}
+		if _, ok := findAccessToken(fake.tokenList(deploymentID), old.ID); ok {
+			t.Errorf("old token has not been deleted")
+		}
+	})
+
+	t.Run("interrupted and resumed", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeReadWrite})
+		// the rotation is interrupted right after the distribution, while it waits for the grace period
+		interruptedCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		_, err := client.RotateAccessToken(interruptedCtx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute: func(context.Context, AccessToken) error {
+				cancel()
+				return nil
+			},
+			GracePeriod:  time.Hour,
+			PollInterval: time.Hour,
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("RotateAccessToken() error = %v, want %v", err, context.Canceled)
+		}
+		if tokens := fake.tokenList(deploymentID); len(tokens) != 2 {
+			t.Fatalf("tokens after interruption = %+v", tokens)
+		}
+
+		opts := RotateAccessTokenOptions{
+			Distribute:  func(context.Context, AccessToken) error { return nil },
+			GracePeriod: time.Millisecond,
+		}
+		result, err := client.RotateAccessToken(ctx, deploymentID, old.ID, opts)
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if !result.Resumed || result.Trigger != AccessTokenRotationGracePeriod {
+			t.Errorf("RotateAccessToken() = %+v", result)
+		}
+		if n := fake.requestCount(http.MethodPost, tokensPath); n != 1 {
+			t.Errorf("RotateAccessToken() created %d tokens, want 1", n)
+		}
+		if tokens := fake.tokenList(deploymentID); len(tokens) != 1 || tokens[0].ID != result.NewToken.ID {
+			t.Errorf("tokens after rotation = %+v", tokens)
+		}
+
+		// the rotation has been completed, so calling it again doesn't change anything
+		result, err = client.RotateAccessToken(ctx, deploymentID, old.ID, opts)
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if result.Trigger != AccessTokenRotationAlreadyDeleted || len(fake.tokenList(deploymentID)) != 1 {
+			t.Errorf("RotateAccessToken() for completed rotation = %+v", result)
+		}
+	})
+
+	t.Run("server clock skew", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeRead})
+		// the replacement created by the interrupted rotation according to the API clock running ahead
+		createdAt := time.Now().Add(time.Hour)
+		fake.addToken(deploymentID, AccessToken{Description: AccessTokenRotationDescription(old.Description, old.ID), Type: AccessModeRead, CreatedAt: createdAt})
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		result, err := client.RotateAccessToken(timeoutCtx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute:   func(context.Context, AccessToken) error { return nil },
+			GracePeriod:  10 * time.Millisecond,
+			PollInterval: 5 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if !result.Resumed || result.Trigger != AccessTokenRotationGracePeriod {
+			t.Errorf("RotateAccessToken() = %+v", result)
+		}
+	})
+
+	t.Run("old token without recorded usage", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeRead})
+
+		result, err := client.RotateAccessToken(ctx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute:   func(context.Context, AccessToken) error { return nil },
+			GracePeriod:  time.Hour,
+			StaleAfter:   10 * time.Millisecond,
+			PollInterval: 5 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("RotateAccessToken() error = %v", err)
+		}
+		if result.Trigger != AccessTokenRotationStale {
+			t.Errorf("RotateAccessToken() trigger = %s, want %s", result.Trigger, AccessTokenRotationStale)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		old := fake.addToken(deploymentID, AccessToken{Description: "ci", Type: AccessModeRead})
+
+		if _, err := client.RotateAccessToken(ctx, deploymentID, old.ID, RotateAccessTokenOptions{}); err == nil {
+			t.Errorf("RotateAccessToken() without distribution callback error = nil, want error")
+		}
+		if _, err := client.RotateAccessToken(ctx, deploymentID, "missing", RotateAccessTokenOptions{
+			Distribute: func(context.Context, AccessToken) error { return nil },
+		}); err == nil {
+			t.Errorf("RotateAccessToken() for missing token error = nil, want error")
+		}
+
+		distributeErr := errors.New("vault is unavailable")
+		_, err := client.RotateAccessToken(ctx, deploymentID, old.ID, RotateAccessTokenOptions{
+			Distribute: func(context.Context, AccessToken) error { return distributeErr },
+		})
+		if !errors.Is(err, distributeErr) {
+			t.Errorf("RotateAccessToken() error = %v, want %v", err, distributeErr)
+		}
+		if _, ok := findAccessToken(fake.tokenList(deploymentID), old.ID); !ok {
+			t.Errorf("old token has been deleted after the failed distribution")
+		}
+	})
+}
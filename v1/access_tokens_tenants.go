@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DefaultTenantTokenDescriptionPrefix - default prefix of descriptions of access tokens provisioned for tenants
+const DefaultTenantTokenDescriptionPrefix = "tenant"
+
+// DefaultTenantAccessModes - access modes of tokens provisioned for every tenant by default
+var DefaultTenantAccessModes = []AccessMode{AccessModeRead, AccessModeWrite}
+
+// ProvisionTenantsOptions - options for ProvisionTenants
+type ProvisionTenantsOptions struct {
+	// Modes - access modes of tokens provisioned for every tenant, DefaultTenantAccessModes are used if empty
+	Modes []AccessMode
+	// DescriptionPrefix - prefix of descriptions of tenant tokens, DefaultTenantTokenDescriptionPrefix is used if empty
+	DescriptionPrefix string
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+	// SecretSink receives created access tokens with revealed secrets (optional)
+	SecretSink AccessTokenSecretSink
+}
+
+// DeprovisionTenantsOptions - options for DeprovisionTenants
+type DeprovisionTenantsOptions struct {
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+}
+
+// TenantTokenDescription returns the description of the access token provisioned by ProvisionTenants
+// for the tenant with the given access mode, e.g. "tenant 12:0 read".
+// DefaultTenantTokenDescriptionPrefix is used if prefix is empty.
+func TenantTokenDescription(prefix string, tenant TenantID, mode AccessMode) string {
+	if prefix == "" {
+		prefix = DefaultTenantTokenDescriptionPrefix
+	}
+	name := mode.String()
+	switch mode {
+	case AccessModeRead:
+		name = "read"
+	case AccessModeWrite:
+		name = "write"
+	case AccessModeReadWrite:
+		name = "read-write"
+	}
+	return prefix + " " + tenant.String() + " " + name
+}
+
+// ProvisionTenants makes sure the cluster deployment has an access token of every mode of opts.Modes for every tenant.
+// Tokens are matched by tenant ID and description generated by TenantTokenDescription, missing tokens are created
+// and passed with revealed secrets to opts.SecretSink. Other tokens of the deployment are left as is and not reported.
+// Single-node deployments are refused, since they don't support tenants.
+func (a *VMCloudAPIClient) ProvisionTenants(ctx context.Context, deployment DeploymentInfo, tenants []TenantID, opts ProvisionTenantsOptions) (ReconcileAccessTokensReport, error) {
+	report := ReconcileAccessTokensReport{DryRun: opts.DryRun}
+	if err := checkTenantsDeployment(deployment); err != nil {
+		return report, err
+	}
+	modes := opts.Modes
+	if len(modes) == 0 {
+		modes = DefaultTenantAccessModes
+	}
+
+	var desired []AccessTokenCreateRequest
+	for _, tenant := range uniqueTenantIDs(tenants) {
+		for _, mode := range modes {
+			desired = append(desired, AccessTokenCreateRequest{
+				Type:        mode,
+				Description: TenantTokenDescription(opts.DescriptionPrefix, tenant, mode),
+				TenantID:    tenant.String(),
+			})
+		}
+	}
+	report, err := a.ReconcileAccessTokens(ctx, deployment.ID, desired, ReconcileAccessTokensOptions{
+		DryRun:     opts.DryRun,
+		SecretSink: opts.SecretSink,
+	})
+	tokens := report.Tokens[:0]
+	for _, token := range report.Tokens {
+		if token.Action != AccessTokenActionUnmanaged {
+			tokens = append(tokens, token)
+		}
+	}
+	report.Tokens = tokens
+	return report, err
+}
+
+// DeprovisionTenants deletes all access tokens of the tenants from the cluster deployment,
+// including tokens that haven't been created by ProvisionTenants. Tokens without a tenant belong to the default tenant 0:0.
+// Single-node deployments are refused, since they don't support tenants.
+func (a *VMCloudAPIClient) DeprovisionTenants(ctx context.Context, deployment DeploymentInfo, tenants []TenantID, opts DeprovisionTenantsOptions) (ReconcileAccessTokensReport, error) {
+	report := ReconcileAccessTokensReport{DryRun: opts.DryRun}
+	if err := checkTenantsDeployment(deployment); err != nil {
+		return report, err
+	}
+	remove := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		remove[tenant.String()] = true
+	}
+
+	existing, err := a.ListDeploymentAccessTokens(ctx, deployment.ID)
+	if err != nil {
+		return report, fmt.Errorf("failed to list access tokens of deployment %q: %w", deployment.ID, err)
+	}
+	var toDelete []AccessToken
+	for _, token := range existing {
+		if remove[normalizeTenantID(token.TenantID)] {
+			toDelete = append(toDelete, token)
+		}
+	}
+	sort.Slice(toDelete, func(i, j int) bool { return toDelete[i].ID < toDelete[j].ID })
+	err = a.applyAccessTokenChanges(ctx, deployment.ID, nil, toDelete, ReconcileAccessTokensOptions{DryRun: opts.DryRun}, &report)
+	sortAccessTokenResults(report.Tokens)
+	return report, err
+}
+
+// checkTenantsDeployment returns an error if the deployment doesn't support tenants
+func checkTenantsDeployment(deployment DeploymentInfo) error {
+	if err := checkDeploymentID(deployment.ID); err != nil {
+		return err
+	}
+	return deployment.checkTenantsSupported()
+}
+
+func uniqueTenantIDs(tenants []TenantID) []TenantID {
+	result := make([]TenantID, 0, len(tenants))
+	seen := make(map[TenantID]bool, len(tenants))
+	for _, tenant := range tenants {
+		if !seen[tenant] {
+			seen[tenant] = true
+			result = append(result, tenant)
+		}
+	}
+	return result
+}
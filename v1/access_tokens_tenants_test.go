@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestProvisionTenants(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	cluster := DeploymentInfo{ID: deploymentID, Type: DeploymentTypeCluster}
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(cluster)
+	fake.addToken(deploymentID, AccessToken{ID: "existing", Description: "tenant 1:0 read", Type: AccessModeRead, TenantID: "1"})
+	fake.addToken(deploymentID, AccessToken{ID: "other", Description: "vmagent", Type: AccessModeWrite})
+	tenants := []TenantID{NewTenantID(1, 0), NewTenantID(2, 5), NewTenantID(1, 0)}
+
+	dryRun, err := client.ProvisionTenants(context.Background(), cluster, tenants, ProvisionTenantsOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ProvisionTenants() in dry-run mode error = %v", err)
+	}
+	if len(dryRun.Tokens) != 4 || len(fake.tokenList(deploymentID)) != 2 {
+		t.Errorf("ProvisionTenants() in dry-run mode = %+v", dryRun)
+	}
+
+	var revealed []string
+	report, err := client.ProvisionTenants(context.Background(), cluster, tenants, ProvisionTenantsOptions{
+		SecretSink: func(_ context.Context, token AccessToken) error {
+			revealed = append(revealed, token.Description)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ProvisionTenants() error = %v", err)
+	}
+	var got []string
+	for _, token := range report.Tokens {
+		got = append(got, token.Description+"="+token.Action.String())
+	}
+	want := []string{
+		"tenant 1:0 read=unchanged",
+		"tenant 1:0 write=created",
+		"tenant 2:5 read=created",
+		"tenant 2:5 write=created",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProvisionTenants() tokens = %v, want %v", got, want)
+	}
+	if len(revealed) != 3 {
+		t.Errorf("ProvisionTenants() revealed %v, want 3 new tokens", revealed)
+	}
+
+	// provisioning is idempotent
+	report, err = client.ProvisionTenants(context.Background(), cluster, tenants, ProvisionTenantsOptions{})
+	if err != nil {
+		t.Fatalf("ProvisionTenants() error = %v", err)
+	}
+	for _, token := range report.Tokens {
+		if token.Action != AccessTokenActionUnchanged {
+			t.Errorf("repeated ProvisionTenants() action for %q = %s", token.Description, token.Action)
+		}
+	}
+
+	report, err = client.DeprovisionTenants(context.Background(), cluster, []TenantID{NewTenantID(1, 0)}, DeprovisionTenantsOptions{})
+	if err != nil {
+		t.Fatalf("DeprovisionTenants() error = %v", err)
+	}
+	if len(report.Tokens) != 2 || report.Tokens[0].Action != AccessTokenActionDeleted {
+		t.Errorf("DeprovisionTenants() = %+v", report)
+	}
+	var remaining []string
+	for _, token := range fake.tokenList(deploymentID) {
+		remaining = append(remaining, token.Description)
+	}
+	if !reflect.DeepEqual(remaining, []string{"vmagent", "tenant 2:5 read", "tenant 2:5 write"}) {
+		t.Errorf("tokens after DeprovisionTenants() = %v", remaining)
+	}
+}
+
+func TestProvisionTenants_SingleNode(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	single := DeploymentInfo{ID: deploymentID, Type: DeploymentTypeSingleNode}
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(single)
+
+	if _, err := client.ProvisionTenants(context.Background(), single, []TenantID{NewTenantID(1, 0)}, ProvisionTenantsOptions{}); err == nil {
+		t.Errorf("ProvisionTenants() for single-node deployment error = nil, want error")
+	}
+	if _, err := client.DeprovisionTenants(context.Background(), single, []TenantID{NewTenantID(1, 0)}, DeprovisionTenantsOptions{}); err == nil {
+		t.Errorf("DeprovisionTenants() for single-node deployment error = nil, want error")
+	}
+	if n := len(fake.requests); n != 0 {
+		t.Errorf("%d requests sent for single-node deployment, want 0", n)
+	}
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -111,6 +112,41 @@ func TestCreateDeploymentAccessToken(t *testing.T) {
 	}
 }
 
+func TestCreateDeploymentAccessToken_TenantID(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Type: DeploymentTypeCluster})
+
+	for _, tenantID := range []string{"12", "12:34"} {
+		token, err := client.CreateDeploymentAccessToken(context.Background(), deploymentID, AccessTokenCreateRequest{
+			Type:        AccessModeRead,
+			Description: "tenant " + tenantID,
+			TenantID:    tenantID,
+		})
+		if err != nil {
+			t.Fatalf("CreateDeploymentAccessToken() with tenant ID %q error = %v", tenantID, err)
+		}
+		if token.TenantID != tenantID {
+			t.Errorf("CreateDeploymentAccessToken() TenantID = %q, want %q", token.TenantID, tenantID)
+		}
+	}
+
+	for _, tenantID := range []string{"abc", "1:2:3", "4294967296"} {
+		_, err := client.CreateDeploymentAccessToken(context.Background(), deploymentID, AccessTokenCreateRequest{
+			Type:        AccessModeRead,
+			Description: "invalid tenant",
+			TenantID:    tenantID,
+		})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("CreateDeploymentAccessToken() with tenant ID %q error = %v, want *ValidationError", tenantID, err)
+		}
+	}
+	if n := len(fake.tokenList(deploymentID)); n != 2 {
+		t.Errorf("CreateDeploymentAccessToken() created %d tokens, want 2", n)
+	}
+}
+
 func TestRevealDeploymentAccessToken(t *testing.T) {
 	// Create a sample response
 	response := AccessToken{
@@ -3,10 +3,14 @@ package v1
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 )
 
 const (
@@ -18,6 +22,8 @@ const (
 	// should be taken from the context instead of the client configuration.
 	// This allows using different API keys for different requests with the same client instance.
 	DynamicAPIKey = "dynamic"
+
+	ruleFileContentVersionPrefix = "sha256:"
 )
 
 // VMCloudAPIClient represents a API client for VictoriaMetrics Cloud API
@@ -26,6 +32,9 @@ type VMCloudAPIClient struct {
 	apiKey    string
 	baseURL   string
 	parsedURL *url.URL
+
+	validateRuleFiles     bool
+	ruleValidationOptions []RuleFileValidationOption
 }
 
 // VMCloudAPIClientOption defines a functional option to configure a VMCloudAPIClient instance.
@@ -45,6 +54,15 @@ func WithBaseURL(baseURL string) VMCloudAPIClientOption {
 	}
 }
 
+// WithRuleFilesValidation enables validation of alerting/recording rule files with ValidateRuleFile before uploading them.
+// The given options enable additional checks during validation.
+func WithRuleFilesValidation(opts ...RuleFileValidationOption) VMCloudAPIClientOption {
+	return func(client *VMCloudAPIClient) {
+		client.validateRuleFiles = true
+		client.ruleValidationOptions = opts
+	}
+}
+
 // New creates a new VMCloudAPIClient instance with the provided API key and options.
 func New(apiKey string, options ...VMCloudAPIClientOption) (*VMCloudAPIClient, error) {
 	if apiKey == "" {
@@ -191,14 +209,8 @@ func (a *VMCloudAPIClient) CreateDeploymentAccessToken(ctx context.Context, depl
 	if err := checkDeploymentID(deploymentID); err != nil {
 		return AccessToken{}, err
 	}
-	if token.Description == "" {
-		return AccessToken{}, fmt.Errorf("access token description cannot be empty")
-	}
-	if token.Type != AccessModeRead && token.Type != AccessModeWrite && token.Type != AccessModeReadWrite {
-		return AccessToken{}, fmt.Errorf("invalid access token type: %s", token.Type)
-	}
-	if isValidTenantID(token.TenantID) {
-		return AccessToken{}, fmt.Errorf("invalid tenant ID format: %s, expected <accountID> or <accountID>:<projectID>", token.TenantID)
+	if err := checkAccessTokenCreateRequest(token); err != nil {
+		return AccessToken{}, err
 	}
 	body, err := json.Marshal(token)
 	if err != nil {
@@ -212,8 +224,8 @@ func (a *VMCloudAPIClient) RevealDeploymentAccessToken(ctx context.Context, depl
 	if err := checkDeploymentID(deploymentID); err != nil {
 		return AccessToken{}, err
 	}
-	if tokenID == "" {
-		return AccessToken{}, fmt.Errorf("token ID cannot be empty")
+	if err := checkPathParam("token ID", tokenID); err != nil {
+		return AccessToken{}, err
 	}
 	return requestAPI[AccessToken](ctx, a, http.MethodGet, nil, "/api/v1/deployments", deploymentID, "access_tokens", tokenID)
 }
@@ -223,8 +235,8 @@ func (a *VMCloudAPIClient) DeleteDeploymentAccessToken(ctx context.Context, depl
 	if err := checkDeploymentID(deploymentID); err != nil {
 		return err
 	}
-	if tokenID == "" {
-		return fmt.Errorf("token ID cannot be empty")
+	if err := checkPathParam("token ID", tokenID); err != nil {
+		return err
 	}
 	_, err := requestAPI[any](ctx, a, http.MethodDelete, nil, "/api/v1/deployments", deploymentID, "access_tokens", tokenID)
 	if err != nil {
@@ -243,25 +255,106 @@ func (a *VMCloudAPIClient) ListDeploymentRuleFileNames(ctx context.Context, depl
 
 // GetDeploymentRuleFileContent retrieves the content of a specific alerting/recording rules file for a deployment by deployment ID and file name.
 func (a *VMCloudAPIClient) GetDeploymentRuleFileContent(ctx context.Context, deploymentID, ruleFileName string) (string, error) {
+	content, _, err := a.GetDeploymentRuleFileContentVersion(ctx, deploymentID, ruleFileName)
+	return content, err
+}
+
+// GetDeploymentRuleFileContentVersion retrieves the content of a specific alerting/recording rules file along with its version.
+// The version is the ETag returned by the API or, if the API doesn't return ETags, "sha256:" followed by the hex-encoded
+// SHA-256 hash of the content. Pass the version to UpdateDeploymentRuleFileContent with WithExpectedRuleFileVersion
+// to refuse the update if the rule file has been modified in the meantime.
+func (a *VMCloudAPIClient) GetDeploymentRuleFileContentVersion(ctx context.Context, deploymentID, ruleFileName string) (string, string, error) {
 	if err := checkDeploymentID(deploymentID); err != nil {
-		return "", err
+		return "", "", err
+	}
+	if err := checkPathParam("rule file name", ruleFileName); err != nil {
+		return "", "", err
 	}
-	if ruleFileName == "" {
-		return "", fmt.Errorf("rule file name cannot be empty")
+	content, header, err := requestAPIWithHeaders[string](ctx, a, http.MethodGet, nil, nil, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
+	if err != nil {
+		return "", "", err
+	}
+	version := header.Get("ETag")
+	if version == "" {
+		version = RuleFileContentVersion(content)
+	}
+	return content, version, nil
+}
+
+// RuleFileContentVersion returns the version of the rule file content used when the API doesn't return ETags
+func RuleFileContentVersion(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return ruleFileContentVersionPrefix + hex.EncodeToString(sum[:])
+}
+
+// RuleFileUpdateOption - option for UpdateDeploymentRuleFileContent
+type RuleFileUpdateOption func(*ruleFileUpdateConfig)
+
+type ruleFileUpdateConfig struct {
+	expectedVersion string
+}
+
+// WithExpectedRuleFileVersion makes UpdateDeploymentRuleFileContent return *RuleFileConflictError
+// if the current version of the rule file differs from the version returned by GetDeploymentRuleFileContentVersion.
+func WithExpectedRuleFileVersion(version string) RuleFileUpdateOption {
+	return func(c *ruleFileUpdateConfig) {
+		c.expectedVersion = version
 	}
-	return requestAPI[string](ctx, a, http.MethodGet, nil, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
 }
 
 // UpdateDeploymentRuleFileContent updates the content of an existing alerting/recording rules file for a deployment by deployment ID and file name.
-func (a *VMCloudAPIClient) UpdateDeploymentRuleFileContent(ctx context.Context, deploymentID, ruleFileName, content string) error {
-	if err := checkDeploymentID(deploymentID); err != nil {
+// It returns *RuleFileNotFoundError if the rule file doesn't exist.
+// The existence check is best-effort: the rule file may be deleted or modified concurrently between the check and the upload.
+// With WithExpectedRuleFileVersion it returns *RuleFileConflictError if the rule file has been modified since the version was retrieved,
+// which should be used by callers that need protection against concurrent modifications.
+func (a *VMCloudAPIClient) UpdateDeploymentRuleFileContent(ctx context.Context, deploymentID, ruleFileName, content string, opts ...RuleFileUpdateOption) error {
+	if err := a.checkRuleFileUpload(deploymentID, ruleFileName, content, false); err != nil {
 		return err
 	}
-	if ruleFileName == "" {
-		return fmt.Errorf("rule file name cannot be empty")
+	var cfg ruleFileUpdateConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.expectedVersion != "" {
+		return a.compareAndSwapRuleFile(ctx, deploymentID, ruleFileName, content, cfg.expectedVersion)
+	}
+	exists, err := a.ruleFileExists(ctx, deploymentID, ruleFileName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &RuleFileNotFoundError{DeploymentID: deploymentID, Name: ruleFileName}
+	}
+	if err := a.uploadDeploymentRuleFile(ctx, deploymentID, ruleFileName, content); err != nil {
+		return fmt.Errorf("failed to update rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
+	}
+	return nil
+}
+
+// compareAndSwapRuleFile uploads the rule file content if the current version of the rule file matches expectedVersion.
+// The current version is re-read before the upload. ETag versions are also sent in If-Match header,
+// so the API rejects the upload if the rule file is modified between the re-read and the upload.
+// Content hash versions can't be checked by the API, so such a concurrent modification is not detected.
+func (a *VMCloudAPIClient) compareAndSwapRuleFile(ctx context.Context, deploymentID, ruleFileName, content, expectedVersion string) error {
+	_, currentVersion, err := a.GetDeploymentRuleFileContentVersion(ctx, deploymentID, ruleFileName)
+	if isAPIErrorStatus(err, http.StatusNotFound) {
+		return &RuleFileNotFoundError{DeploymentID: deploymentID, Name: ruleFileName}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get rule file %q of deployment %q: %w", ruleFileName, deploymentID, err)
+	}
+	if currentVersion != expectedVersion {
+		return &RuleFileConflictError{DeploymentID: deploymentID, Name: ruleFileName, ExpectedVersion: expectedVersion, ActualVersion: currentVersion}
+	}
+	var header http.Header
+	if !strings.HasPrefix(expectedVersion, ruleFileContentVersionPrefix) {
+		header = http.Header{"If-Match": []string{expectedVersion}}
 	}
 	body := bytes.NewBufferString(content)
-	_, err := requestAPI[any](ctx, a, http.MethodPost, body, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
+	_, _, err = requestAPIWithHeaders[any](ctx, a, http.MethodPost, header, body, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
+	if isAPIErrorStatus(err, http.StatusPreconditionFailed) {
+		return &RuleFileConflictError{DeploymentID: deploymentID, Name: ruleFileName, ExpectedVersion: expectedVersion}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
 	}
@@ -269,28 +362,155 @@ func (a *VMCloudAPIClient) UpdateDeploymentRuleFileContent(ctx context.Context,
 }
 
 // CreateDeploymentRuleFileContent creates a new alerting/recording rules file for a deployment by deployment ID and file name.
+// It returns *RuleFileAlreadyExistsError if the rule file already exists.
+// The existence check is best-effort: the API has no create-only request, so concurrent calls creating the same file
+// may all succeed and the last upload wins. Use WithExpectedRuleFileVersion with UpdateDeploymentRuleFileContent
+// for subsequent modifications that must not overwrite concurrent changes.
 func (a *VMCloudAPIClient) CreateDeploymentRuleFileContent(ctx context.Context, deploymentID, ruleFileName, content string) error {
+	if err := a.checkRuleFileUpload(deploymentID, ruleFileName, content, true); err != nil {
+		return err
+	}
+	exists, err := a.ruleFileExists(ctx, deploymentID, ruleFileName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &RuleFileAlreadyExistsError{DeploymentID: deploymentID, Name: ruleFileName}
+	}
+	if err := a.uploadDeploymentRuleFile(ctx, deploymentID, ruleFileName, content); err != nil {
+		return fmt.Errorf("failed to create rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
+	}
+	return nil
+}
+
+// UpsertDeploymentRuleFile creates or replaces the alerting/recording rules file for a deployment by deployment ID and file name.
+// It returns true if the rule file didn't exist and was created. Names of new rule files must pass ValidateRuleFileName,
+// existing rule files with legacy names can be replaced.
+func (a *VMCloudAPIClient) UpsertDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName, content string) (bool, error) {
+	if err := a.checkRuleFileUpload(deploymentID, ruleFileName, content, false); err != nil {
+		return false, err
+	}
+	exists, err := a.ruleFileExists(ctx, deploymentID, ruleFileName)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err := ValidateRuleFileName(ruleFileName); err != nil {
+			return false, err
+		}
+	}
+	if err := a.uploadDeploymentRuleFile(ctx, deploymentID, ruleFileName, content); err != nil {
+		return false, fmt.Errorf("failed to upload rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
+	}
+	return !exists, nil
+}
+
+// upsertRuleFiles uploads rule files given by name in the order of names and reports whether they were created or updated.
+// On error, the returned results contain the files processed before the failure.
+func (a *VMCloudAPIClient) upsertRuleFiles(ctx context.Context, deploymentID string, files map[string]string) ([]RuleFileResult, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	// check all names before uploading, so invalid names don't leave the deployment partially updated
+	for _, name := range names {
+		if err := ValidateRuleFileName(name); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]RuleFileResult, 0, len(names))
+	for _, name := range names {
+		created, err := a.UpsertDeploymentRuleFile(ctx, deploymentID, name, files[name])
+		if err != nil {
+			return results, err
+		}
+		action := RuleFileActionUpdated
+		if created {
+			action = RuleFileActionCreated
+		}
+		results = append(results, RuleFileResult{Name: name, Action: action})
+	}
+	return results, nil
+}
+
+// checkRuleFileUpload validates arguments of the rule file upload and the content if rule files validation is enabled.
+// The name is checked by ValidateRuleFileName only if create is set, so existing rule files with legacy names
+// returned by ListDeploymentRuleFileNames can still be updated.
+func (a *VMCloudAPIClient) checkRuleFileUpload(deploymentID, ruleFileName, content string, create bool) error {
 	if err := checkDeploymentID(deploymentID); err != nil {
 		return err
 	}
-	if ruleFileName == "" {
-		return fmt.Errorf("rule file name cannot be empty")
+	if create {
+		if err := ValidateRuleFileName(ruleFileName); err != nil {
+			return err
+		}
+	} else if err := checkPathParam("rule file name", ruleFileName); err != nil {
+		return err
 	}
-	body := bytes.NewBufferString(content)
-	_, err := requestAPI[any](ctx, a, http.MethodPost, body, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
+	if a.validateRuleFiles {
+		if err := ValidateRuleFile(ruleFileName, content, a.ruleValidationOptions...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleFileExists checks whether the rule file is present in the list of rule files of the deployment
+func (a *VMCloudAPIClient) ruleFileExists(ctx context.Context, deploymentID, ruleFileName string) (bool, error) {
+	names, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
 	if err != nil {
-		return fmt.Errorf("failed to update rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
+		return false, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	return slices.Contains(names, ruleFileName), nil
+}
+
+// getDeploymentRuleFiles returns contents of all rule files of the deployment by file name
+func (a *VMCloudAPIClient) getDeploymentRuleFiles(ctx context.Context, deploymentID string) (map[string]string, error) {
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return nil, err
+	}
+	names, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		content, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rule file %q of deployment %q: %w", name, deploymentID, err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// putRuleFile validates and uploads the rule file content for callers that already know whether the rule file exists
+func (a *VMCloudAPIClient) putRuleFile(ctx context.Context, deploymentID, ruleFileName, content string, create bool) error {
+	if err := a.checkRuleFileUpload(deploymentID, ruleFileName, content, create); err != nil {
+		return err
+	}
+	if err := a.uploadDeploymentRuleFile(ctx, deploymentID, ruleFileName, content); err != nil {
+		return fmt.Errorf("failed to upload rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
 	}
 	return nil
 }
 
+// uploadDeploymentRuleFile uploads the rule file content without checking its existence.
+// The API uses the same request for creating and replacing rule files.
+func (a *VMCloudAPIClient) uploadDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName, content string) error {
+	body := bytes.NewBufferString(content)
+	_, err := requestAPI[any](ctx, a, http.MethodPost, body, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
+	return err
+}
+
 // DeleteDeploymentRuleFile deletes an existing alerting/recording rules file for a deployment by deployment ID and file name.
 func (a *VMCloudAPIClient) DeleteDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName string) error {
 	if err := checkDeploymentID(deploymentID); err != nil {
 		return err
 	}
-	if ruleFileName == "" {
-		return fmt.Errorf("rule file name cannot be empty")
+	if err := checkPathParam("rule file name", ruleFileName); err != nil {
+		return err
 	}
 	_, err := requestAPI[any](ctx, a, http.MethodDelete, nil, "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
 	if err != nil {
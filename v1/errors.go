@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError is returned when VictoriaMetrics Cloud API responds with a non-2xx status code
+type APIError struct {
+	// StatusCode - HTTP status code of the response
+	StatusCode int
+	// Body - body of the response
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// isAPIErrorStatus returns true if err is *APIError with the given status code
+func isAPIErrorStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// RuleFileAlreadyExistsError is returned when creating an alerting/recording rules file that already exists
+type RuleFileAlreadyExistsError struct {
+	// DeploymentID - identifier of the deployment
+	DeploymentID string
+	// Name - name of the rule file
+	Name string
+}
+
+func (e *RuleFileAlreadyExistsError) Error() string {
+	return fmt.Sprintf("rule file %q already exists in deployment %q", e.Name, e.DeploymentID)
+}
+
+// RuleFileNotFoundError is returned when updating an alerting/recording rules file that doesn't exist
+type RuleFileNotFoundError struct {
+	// DeploymentID - identifier of the deployment
+	DeploymentID string
+	// Name - name of the rule file
+	Name string
+}
+
+func (e *RuleFileNotFoundError) Error() string {
+	return fmt.Sprintf("rule file %q not found in deployment %q", e.Name, e.DeploymentID)
+}
+
+// RuleFileConflictError is returned when updating an alerting/recording rules file that has been modified
+// since the expected version was retrieved
+type RuleFileConflictError struct {
+	// DeploymentID - identifier of the deployment
+	DeploymentID string
+	// Name - name of the rule file
+	Name string
+	// ExpectedVersion - version passed to the update
+	ExpectedVersion string
+	// ActualVersion - current version of the rule file, empty if the conflict was detected by the API
+	ActualVersion string
+}
+
+func (e *RuleFileConflictError) Error() string {
+	if e.ActualVersion == "" {
+		return fmt.Sprintf("rule file %q in deployment %q has been modified since version %q", e.Name, e.DeploymentID, e.ExpectedVersion)
+	}
+	return fmt.Sprintf("rule file %q in deployment %q has been modified: expected version %q, actual version %q",
+		e.Name, e.DeploymentID, e.ExpectedVersion, e.ActualVersion)
+}
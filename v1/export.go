@@ -0,0 +1,356 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AccessTokenSpec - metadata of the access token in exported deployment spec (secrets are never exported)
+type AccessTokenSpec struct {
+	// ID is the unique identifier of the access token
+	ID string `json:"id"`
+	// Description is the human-readable description of the access token
+	Description string `json:"description"`
+	// Type is the access mode of the token (read-only, write-only, read+write)
+	Type AccessMode `json:"type"`
+	// TenantID represents the unique identifier of the tenant associated with this access token (optional)
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// DeploymentSpec - normalized and reproducible configuration of the deployment.
+// It contains only user-controlled settings, so it doesn't change between exports unless the deployment is reconfigured.
+type DeploymentSpec struct {
+	// ID - unique identifier of the deployment
+	ID string `json:"id"`
+	// Name - human-readable name of the deployment
+	Name string `json:"name"`
+	// Type of the deployment (single_node / cluster)
+	Type DeploymentType `json:"type"`
+	// Provider - cloud provider of the deployment
+	Provider DeploymentCloudProvider `json:"provider"`
+	// Region of the deployment in specified cloud provider
+	Region string `json:"region"`
+	// Tier - tier identifier of the deployment
+	Tier uint32 `json:"tier"`
+	// StorageSize - storage size in units specified in StorageSizeUnit
+	StorageSize uint64 `json:"storage_size"`
+	// StorageSizeUnit - storage size unit (GB / TB)
+	StorageSizeUnit StorageUnit `json:"storage_size_unit"`
+	// Deduplication window for the deployment in units specified in DeduplicationUnit
+	Deduplication uint32 `json:"deduplication"`
+	// DeduplicationUnit - deduplication window unit for the deployment
+	DeduplicationUnit DurationUnit `json:"deduplication_unit"`
+	// Retention period for the deployment in units specified in RetentionUnit
+	Retention uint32 `json:"retention"`
+	// RetentionUnit - retention period unit for the deployment
+	RetentionUnit DurationUnit `json:"retention_unit"`
+	// MaintenanceWindow - maintenance window for the deployment
+	MaintenanceWindow MaintenanceWindow `json:"maintenance_window"`
+	// Flags - customized command-line flags for the deployment
+	Flags DeploymentFlags `json:"flags"`
+	// AccessTokens - access tokens of the deployment sorted by description, tenant and ID
+	AccessTokens []AccessTokenSpec `json:"access_tokens"`
+	// RuleFiles - sorted names of alerting/recording rule files of the deployment
+	RuleFiles []string `json:"rule_files"`
+}
+
+// CreationRequest returns the request for creating a deployment with the same configuration as in the spec.
+func (s DeploymentSpec) CreationRequest() DeploymentCreationRequest {
+	return DeploymentCreationRequest{
+		Name:              s.Name,
+		Type:              s.Type,
+		Provider:          s.Provider,
+		Region:            s.Region,
+		Tier:              s.Tier,
+		StorageSize:       s.StorageSize,
+		StorageSizeUnit:   s.StorageSizeUnit,
+		Deduplication:     s.Deduplication,
+		DeduplicationUnit: s.DeduplicationUnit,
+		Retention:         s.Retention,
+		RetentionUnit:     s.RetentionUnit,
+		MaintenanceWindow: s.MaintenanceWindow,
+	}
+}
+
+// UpdateRequest returns the request for updating a deployment to the configuration from the spec.
+func (s DeploymentSpec) UpdateRequest() DeploymentUpdateRequest {
+	return DeploymentUpdateRequest{
+		Name:              s.Name,
+		Tier:              s.Tier,
+		StorageSize:       s.StorageSize,
+		StorageSizeUnit:   s.StorageSizeUnit,
+		Deduplication:     s.Deduplication,
+		DeduplicationUnit: s.DeduplicationUnit,
+		Retention:         s.Retention,
+		RetentionUnit:     s.RetentionUnit,
+		MaintenanceWindow: s.MaintenanceWindow,
+		Flags:             s.Flags,
+	}
+}
+
+// RuleFileExport - alerting/recording rules file with its content
+type RuleFileExport struct {
+	// Name - name of the rule file
+	Name string
+	// Content - content of the rule file
+	Content string
+}
+
+// DeploymentExport - exported configuration of the deployment
+type DeploymentExport struct {
+	// Spec - normalized configuration of the deployment
+	Spec DeploymentSpec
+	// RuleFiles - alerting/recording rule files of the deployment sorted by name
+	RuleFiles []RuleFileExport
+}
+
+// AccountExport - exported configuration of all deployments of the account
+type AccountExport struct {
+	// Deployments - exported deployments sorted by name and ID
+	Deployments []DeploymentExport
+}
+
+// ExportAccount collects the configuration of all deployments of the current account (API Key),
+// including access tokens metadata and alerting/recording rule files.
+// The result is normalized and has stable ordering, so it can be stored in VCS with AccountExport.WriteDir.
+func (a *VMCloudAPIClient) ExportAccount(ctx context.Context) (AccountExport, error) {
+	deployments, err := a.ListDeployments(ctx)
+	if err != nil {
+		return AccountExport{}, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	var result AccountExport
+	for _, deployment := range deployments {
+		exported, err := a.ExportDeployment(ctx, deployment.ID)
+		if err != nil {
+			return AccountExport{}, err
+		}
+		result.Deployments = append(result.Deployments, exported)
+	}
+	sort.Slice(result.Deployments, func(i, j int) bool {
+		si, sj := result.Deployments[i].Spec, result.Deployments[j].Spec
+		if si.Name != sj.Name {
+			return si.Name < sj.Name
+		}
+		return si.ID < sj.ID
+	})
+	return result, nil
+}
+
+// ExportDeployment collects the configuration of a specific deployment by deployment ID,
+// including access tokens metadata and alerting/recording rule files.
+func (a *VMCloudAPIClient) ExportDeployment(ctx context.Context, deploymentID string) (DeploymentExport, error) {
+	info, err := a.GetDeploymentDetails(ctx, deploymentID)
+	if err != nil {
+		return DeploymentExport{}, fmt.Errorf("failed to get details of deployment %q: %w", deploymentID, err)
+	}
+	tokens, err := a.ListDeploymentAccessTokens(ctx, deploymentID)
+	if err != nil {
+		return DeploymentExport{}, fmt.Errorf("failed to list access tokens of deployment %q: %w", deploymentID, err)
+	}
+	ruleFileNames, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return DeploymentExport{}, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	sort.Strings(ruleFileNames)
+
+	result := DeploymentExport{
+		Spec: newDeploymentSpec(info, tokens, ruleFileNames),
+	}
+	for _, name := range ruleFileNames {
+		content, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, name)
+		if err != nil {
+			return DeploymentExport{}, fmt.Errorf("failed to get rule file %q of deployment %q: %w", name, deploymentID, err)
+		}
+		result.RuleFiles = append(result.RuleFiles, RuleFileExport{Name: name, Content: content})
+	}
+	return result, nil
+}
+
+func newDeploymentSpec(info DeploymentInfo, tokens AccessTokensList, ruleFileNames []string) DeploymentSpec {
+	spec := DeploymentSpec{
+		ID:                info.ID,
+		Name:              info.Name,
+		Type:              info.Type,
+		Provider:          info.CloudProvider,
+		Region:            info.Region,
+		Tier:              info.Tier,
+		StorageSize:       info.StorageSizeGb,
+		StorageSizeUnit:   StorageUnitGB,
+		Deduplication:     info.DeduplicationValue,
+		DeduplicationUnit: info.DeduplicationUnit,
+		Retention:         info.RetentionValue,
+		RetentionUnit:     info.RetentionUnit,
+		MaintenanceWindow: info.MaintenanceWindow,
+		Flags: DeploymentFlags{
+			SingleFlags:  normalizeFlagList(info.VMSingleSettings),
+			SelectFlags:  normalizeFlagList(info.VMSelectSettings),
+			StorageFlags: normalizeFlagList(info.VMStorageSettings),
+			InsertFlags:  normalizeFlagList(info.VMInsertSettings),
+		},
+		AccessTokens: make([]AccessTokenSpec, 0, len(tokens)),
+		RuleFiles:    append([]string{}, ruleFileNames...),
+	}
+	for _, token := range tokens {
+		spec.AccessTokens = append(spec.AccessTokens, AccessTokenSpec{
+			ID:          token.ID,
+			Description: token.Description,
+			Type:        token.Type,
+			TenantID:    token.TenantID,
+		})
+	}
+	sort.Slice(spec.AccessTokens, func(i, j int) bool {
+		ti, tj := spec.AccessTokens[i], spec.AccessTokens[j]
+		if ti.Description != tj.Description {
+			return ti.Description < tj.Description
+		}
+		if ti.TenantID != tj.TenantID {
+			return ti.TenantID < tj.TenantID
+		}
+		return ti.ID < tj.ID
+	})
+	return spec
+}
+
+// normalizeFlagList returns an empty list instead of nil, so exported specs don't flip between null and []
+func normalizeFlagList(flags []string) FlagList {
+	return append(FlagList{}, flags...)
+}
+
+// WriteDir writes the exported configuration into the given directory using the following layout:
+//
+//	<dir>/deployments/<deployment-name>/deployment.json
+//	<dir>/deployments/<deployment-name>/rules/<rule-file-name>
+//
+// The deployments subdirectory is fully managed by WriteDir: it is replaced on every write,
+// so deployments and rule files deleted in the account disappear from the exported tree as well.
+// The new export is written into a temporary directory first, so the previous export is left intact
+// if names cannot be used as file names or writing fails.
+func (e AccountExport) WriteDir(dir string) error {
+	dirNames, err := exportDirNames(e.Deployments)
+	if err != nil {
+		return err
+	}
+	for _, deployment := range e.Deployments {
+		if err := deployment.checkRuleFileNames(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", dir, err)
+	}
+	tmpDir, err := os.MkdirTemp(dir, ".deployments.tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory in %q: %w", dir, err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	if err := os.Chmod(tmpDir, 0o755); err != nil {
+		return fmt.Errorf("failed to change permissions of directory %q: %w", tmpDir, err)
+	}
+	for i, deployment := range e.Deployments {
+		if err := deployment.writeDir(filepath.Join(tmpDir, dirNames[i])); err != nil {
+			return err
+		}
+	}
+	deploymentsDir := filepath.Join(dir, "deployments")
+	if err := os.RemoveAll(deploymentsDir); err != nil {
+		return fmt.Errorf("failed to clean up directory %q: %w", deploymentsDir, err)
+	}
+	if err := os.Rename(tmpDir, deploymentsDir); err != nil {
+		return fmt.Errorf("failed to move exported deployments into %q: %w", deploymentsDir, err)
+	}
+	return nil
+}
+
+// checkRuleFileNames checks that names of rule files can be used as file names
+func (e DeploymentExport) checkRuleFileNames() error {
+	for _, ruleFile := range e.RuleFiles {
+		if !filepath.IsLocal(ruleFile.Name) || strings.ContainsAny(ruleFile.Name, `/\`) {
+			return fmt.Errorf("rule file name %q of deployment %q cannot be used as a file name", ruleFile.Name, e.Spec.ID)
+		}
+	}
+	return nil
+}
+
+func (e DeploymentExport) writeDir(dir string) error {
+	rulesDir := filepath.Join(dir, "rules")
+	if err := os.MkdirAll(rulesDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", rulesDir, err)
+	}
+	spec, err := json.MarshalIndent(e.Spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal spec of deployment %q: %w", e.Spec.ID, err)
+	}
+	specPath := filepath.Join(dir, "deployment.json")
+	if err := os.WriteFile(specPath, append(spec, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", specPath, err)
+	}
+	for _, ruleFile := range e.RuleFiles {
+		rulePath := filepath.Join(rulesDir, ruleFile.Name)
+		if err := os.WriteFile(rulePath, []byte(ruleFile.Content), 0o644); err != nil {
+			return fmt.Errorf("failed to write file %q: %w", rulePath, err)
+		}
+	}
+	return nil
+}
+
+// exportDirNames returns directory names for exported deployments.
+// Names are derived from deployment names; deployments with clashing names get the ID prefix appended,
+// and the full ID if the prefixed names still clash, so directory names don't depend on the order of deployments.
+func exportDirNames(deployments []DeploymentExport) ([]string, error) {
+	base := make([]string, len(deployments))
+	for i, deployment := range deployments {
+		base[i] = sanitizeExportName(deployment.Spec.Name)
+	}
+	names := make([]string, len(deployments))
+	clashes := countExportDirNames(base)
+	for i, deployment := range deployments {
+		names[i] = base[i]
+		if base[i] == "" || clashes[base[i]] > 1 {
+			id := deployment.Spec.ID
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			names[i] = strings.TrimPrefix(base[i]+"-"+sanitizeExportName(id), "-")
+		}
+	}
+	clashes = countExportDirNames(names)
+	for i, deployment := range deployments {
+		if clashes[names[i]] > 1 {
+			names[i] = strings.TrimPrefix(base[i]+"-"+sanitizeExportName(deployment.Spec.ID), "-")
+		}
+	}
+	seen := make(map[string]string, len(names))
+	for i, deployment := range deployments {
+		if previous, ok := seen[names[i]]; ok || names[i] == "" {
+			return nil, fmt.Errorf("deployments %q and %q cannot be exported into distinct directories", previous, deployment.Spec.ID)
+		}
+		seen[names[i]] = deployment.Spec.ID
+	}
+	return names, nil
+}
+
+func countExportDirNames(names []string) map[string]int {
+	counts := make(map[string]int, len(names))
+	for _, name := range names {
+		counts[name]++
+	}
+	return counts
+}
+
+func sanitizeExportName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('-')
+		}
+	}
+	return strings.Trim(sb.String(), "-.")
+}
@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportAccount(t *testing.T) {
+	fake, client := newFakeCloud(t)
+	first := "123e4567-e89b-12d3-a456-426614174000"
+	second := "223e4567-e89b-12d3-a456-426614174001"
+	fake.addDeployment(DeploymentInfo{
+		ID:                 second,
+		Name:               "Prod Cluster",
+		Type:               DeploymentTypeCluster,
+		CloudProvider:      DeploymentCloudProviderAWS,
+		Region:             "us-east-2",
+		Tier:               7,
+		CreatedAt:          time.Now(),
+		Status:             DeploymentStatusRunning,
+		RetentionValue:     30,
+		RetentionUnit:      DurationUnitDay,
+		DeduplicationValue: 10,
+		DeduplicationUnit:  DurationUnitSecond,
+		StorageSizeGb:      100,
+		MaintenanceWindow:  MaintenanceWindowWeekendDays,
+		VMSelectSettings:   []string{"-search.maxQueryDuration=1m"},
+	})
+	fake.addDeployment(DeploymentInfo{
+		ID:                first,
+		Name:              "dev",
+		Type:              DeploymentTypeSingleNode,
+		CloudProvider:     DeploymentCloudProviderAWS,
+		Region:            "us-east-2",
+		Tier:              21,
+		RetentionValue:    1,
+		RetentionUnit:     DurationUnitMonth,
+		DeduplicationUnit: DurationUnitSecond,
+		StorageSizeGb:     10,
+		MaintenanceWindow: MaintenanceWindowBusinessDays,
+	})
+	fake.addToken(second, AccessToken{ID: "token-b", Description: "vmagent", Type: AccessModeWrite})
+	fake.addToken(second, AccessToken{ID: "token-a", Description: "grafana", Type: AccessModeRead, TenantID: "1:0"})
+	fake.addRuleFile(second, "b.yml", "groups: []\n")
+	fake.addRuleFile(second, "a.yml", "groups:\n- name: a\n  rules: []\n")
+
+	export, err := client.ExportAccount(context.Background())
+	if err != nil {
+		t.Fatalf("ExportAccount() error = %v", err)
+	}
+	if len(export.Deployments) != 2 {
+		t.Fatalf("ExportAccount() returned %d deployments, want 2", len(export.Deployments))
+	}
+	if export.Deployments[0].Spec.Name != "Prod Cluster" || export.Deployments[1].Spec.Name != "dev" {
+		t.Errorf("ExportAccount() deployments are not sorted by name: %q, %q", export.Deployments[0].Spec.Name, export.Deployments[1].Spec.Name)
+	}
+	spec := export.Deployments[0].Spec
+	if spec.AccessTokens[0].Description != "grafana" || spec.AccessTokens[1].Description != "vmagent" {
+		t.Errorf("ExportAccount() access tokens are not sorted: %+v", spec.AccessTokens)
+	}
+	if len(spec.RuleFiles) != 2 || spec.RuleFiles[0] != "a.yml" {
+		t.Errorf("ExportAccount() rule files = %v, want [a.yml b.yml]", spec.RuleFiles)
+	}
+	if spec.StorageSize != 100 || spec.StorageSizeUnit != StorageUnitGB {
+		t.Errorf("ExportAccount() storage = %d %s, want 100 GB", spec.StorageSize, spec.StorageSizeUnit)
+	}
+
+	dir := t.TempDir()
+	// stale files from the previous export must be removed
+	staleFile := filepath.Join(dir, "deployments", "removed", "deployment.json")
+	if err := os.MkdirAll(filepath.Dir(staleFile), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(staleFile, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := export.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+	if _, err := os.Stat(staleFile); !os.IsNotExist(err) {
+		t.Errorf("WriteDir() did not remove stale file %q", staleFile)
+	}
+
+	specData, err := os.ReadFile(filepath.Join(dir, "deployments", "prod-cluster", "deployment.json"))
+	if err != nil {
+		t.Fatalf("Failed to read exported spec: %v", err)
+	}
+	var written DeploymentSpec
+	if err := json.Unmarshal(specData, &written); err != nil {
+		t.Fatalf("Failed to unmarshal exported spec: %v", err)
+	}
+	if written.ID != second {
+		t.Errorf("WriteDir() spec ID = %s, want %s", written.ID, second)
+	}
+	ruleData, err := os.ReadFile(filepath.Join(dir, "deployments", "prod-cluster", "rules", "a.yml"))
+	if err != nil {
+		t.Fatalf("Failed to read exported rule file: %v", err)
+	}
+	if string(ruleData) != "groups:\n- name: a\n  rules: []\n" {
+		t.Errorf("WriteDir() rule file content = %q", ruleData)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "deployments", "dev", "deployment.json")); err != nil {
+		t.Errorf("WriteDir() did not write spec of the second deployment: %v", err)
+	}
+
+	// The second export must produce byte-identical output
+	again, err := client.ExportAccount(context.Background())
+	if err != nil {
+		t.Fatalf("ExportAccount() error = %v", err)
+	}
+	dir2 := t.TempDir()
+	if err := again.WriteDir(dir2); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+	specData2, err := os.ReadFile(filepath.Join(dir2, "deployments", "prod-cluster", "deployment.json"))
+	if err != nil {
+		t.Fatalf("Failed to read exported spec: %v", err)
+	}
+	if string(specData) != string(specData2) {
+		t.Errorf("WriteDir() output is not stable:\n%s\n%s", specData, specData2)
+	}
+}
+
+func TestWriteDir_KeepsPreviousExportOnError(t *testing.T) {
+	dir := t.TempDir()
+	valid := AccountExport{Deployments: []DeploymentExport{{
+		Spec:      DeploymentSpec{ID: "d1", Name: "prod"},
+		RuleFiles: []RuleFileExport{{Name: "alerts.yml", Content: "groups: []\n"}},
+	}}}
+	if err := valid.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+
+	invalid := AccountExport{Deployments: []DeploymentExport{
+		{Spec: DeploymentSpec{ID: "d2", Name: "dev"}},
+		{
+			Spec:      DeploymentSpec{ID: "d1", Name: "prod"},
+			RuleFiles: []RuleFileExport{{Name: "../alerts.yml", Content: "groups: []\n"}},
+		},
+	}}
+	if err := invalid.WriteDir(dir); err == nil {
+		t.Fatalf("WriteDir() with invalid rule file name error = nil, want error")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "deployments", "prod", "rules", "alerts.yml"))
+	if err != nil || string(data) != "groups: []\n" {
+		t.Errorf("WriteDir() did not keep the previous export: %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "deployments" {
+		t.Errorf("WriteDir() left unexpected entries in the directory: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "deployments", "dev")); !os.IsNotExist(err) {
+		t.Errorf("WriteDir() wrote a partial export")
+	}
+}
+
+func TestExportDirNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		deployments []DeploymentExport
+		want        []string
+	}{
+		{
+			name: "clashing names",
+			deployments: []DeploymentExport{
+				{Spec: DeploymentSpec{ID: "123e4567-e89b", Name: "prod"}},
+				{Spec: DeploymentSpec{ID: "223e4567-e89b", Name: "Prod"}},
+				{Spec: DeploymentSpec{ID: "323e4567-e89b", Name: "../"}},
+				{Spec: DeploymentSpec{ID: "423e4567-e89b", Name: "my deployment"}},
+			},
+			want: []string{"prod-123e4567", "prod-223e4567", "323e4567", "my-deployment"},
+		},
+		{
+			name: "clashing ID prefixes",
+			deployments: []DeploymentExport{
+				{Spec: DeploymentSpec{ID: "11111111-aaaa", Name: "a"}},
+				{Spec: DeploymentSpec{ID: "11111111-bbbb", Name: "a"}},
+				{Spec: DeploymentSpec{ID: "22222222-cccc", Name: "b"}},
+			},
+			want: []string{"a-11111111-aaaa", "a-11111111-bbbb", "b"},
+		},
+		{
+			name: "name clashing with suffixed name",
+			deployments: []DeploymentExport{
+				{Spec: DeploymentSpec{ID: "11111111-aaaa", Name: "a"}},
+				{Spec: DeploymentSpec{ID: "22222222-bbbb", Name: "a"}},
+				{Spec: DeploymentSpec{ID: "33333333-cccc", Name: "a-11111111"}},
+			},
+			want: []string{"a-11111111-aaaa", "a-22222222", "a-11111111-33333333-cccc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := exportDirNames(tt.deployments)
+			if err != nil {
+				t.Fatalf("exportDirNames() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exportDirNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	duplicateIDs := []DeploymentExport{
+		{Spec: DeploymentSpec{ID: "11111111-aaaa", Name: "a"}},
+		{Spec: DeploymentSpec{ID: "11111111-aaaa", Name: "a"}},
+	}
+	if _, err := exportDirNames(duplicateIDs); err == nil {
+		t.Errorf("exportDirNames() for deployments with the same ID error = nil, want error")
+	}
+}
@@ -0,0 +1,82 @@
+// Package exprcheck validates expressions of alerting/recording rules with the VictoriaMetrics MetricsQL parser.
+//
+// It is a separate module, so the client library itself doesn't depend on the MetricsQL parser.
+package exprcheck
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/VictoriaMetrics/metricsql"
+
+	vmcloud "github.com/VictoriaMetrics/victoriametrics-cloud-api-go/v1"
+)
+
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// CheckExpr parses the MetricsQL expression and returns the syntax error if it is invalid.
+func CheckExpr(expr string) error {
+	if _, err := metricsql.Parse(expr); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckMetricName returns an error if name is not a valid metric name.
+func CheckMetricName(name string) error {
+	if !metricNameRegex.MatchString(name) {
+		return fmt.Errorf("metric name must match %s", metricNameRegex)
+	}
+	return nil
+}
+
+// Options returns options of vmcloud.ValidateRuleFile enabling MetricsQL expression and metric name checks.
+// They can also be passed to vmcloud.WithRuleFilesValidation to check rule files before uploading.
+func Options() []vmcloud.RuleFileValidationOption {
+	return []vmcloud.RuleFileValidationOption{
+		vmcloud.WithRuleExprCheck(CheckExpr),
+		vmcloud.WithRecordingRuleNameCheck(CheckMetricName),
+	}
+}
+
+// DependencyOptions returns options of vmcloud.AnalyzeRuleDependencies extracting metric names with the MetricsQL parser.
+func DependencyOptions() []vmcloud.RuleDependencyOption {
+	return []vmcloud.RuleDependencyOption{
+		vmcloud.WithRuleMetricExtractor(MetricNames),
+	}
+}
+
+// ValidateRuleFile validates the structure of the rule file with vmcloud.ValidateRuleFile
+// and additionally checks MetricsQL expressions and recording rule names.
+func ValidateRuleFile(name, content string) error {
+	return vmcloud.ValidateRuleFile(name, content, Options()...)
+}
+
+// MetricNames returns sorted unique metric names referenced by the MetricsQL expression.
+func MetricNames(expr string) ([]string, error) {
+	e, err := metricsql.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	metricsql.VisitAll(e, func(expr metricsql.Expr) {
+		me, ok := expr.(*metricsql.MetricExpr)
+		if !ok {
+			return
+		}
+		for _, filters := range me.LabelFilterss {
+			for _, f := range filters {
+				if f.Label == "__name__" && !f.IsRegexp && !f.IsNegative && f.Value != "" {
+					seen[f.Value] = true
+				}
+			}
+		}
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
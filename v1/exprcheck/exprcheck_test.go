@@ -0,0 +1,90 @@
+package exprcheck
+
+import (
+	"errors"
+	"testing"
+
+	vmcloud "github.com/VictoriaMetrics/victoriametrics-cloud-api-go/v1"
+)
+
+func TestCheckExpr(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: `up == 0`},
+		{expr: `sum(rate(http_requests_total{job="api"}[5m])) by (instance) > 10`},
+		{expr: `histogram_quantile(0.99, sum(rate(duration_seconds_bucket[5m])) by (le))`},
+		{expr: `sum(rate(http_requests_total[5m])`, wantErr: true},
+		{expr: `up{job="api"`, wantErr: true},
+		{expr: `rate(x[5m]) >`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if err := CheckExpr(tt.expr); (err != nil) != tt.wantErr {
+				t.Errorf("CheckExpr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckMetricName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "job:http_requests:rate5m"},
+		{name: "_private"},
+		{name: "1st_metric", wantErr: true},
+		{name: "metric-name", wantErr: true},
+		{name: "metric name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckMetricName(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("CheckMetricName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRuleFile(t *testing.T) {
+	content := `groups:
+- name: a
+  rules:
+  - record: job-requests:rate5m
+    expr: sum(rate(requests_total[5m])) by (job)
+  - alert: HighErrorRate
+    expr: sum(rate(errors_total[5m]) > 0
+`
+	err := ValidateRuleFile("rules.yml", content)
+	var validationErr *vmcloud.RuleFileValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidateRuleFile() error = %v, want RuleFileValidationError", err)
+	}
+	if len(validationErr.Issues) != 2 {
+		t.Fatalf("ValidateRuleFile() returned %d issues, want 2:\n%s", len(validationErr.Issues), err)
+	}
+	if issue := validationErr.Issues[0]; issue.Line != 4 || issue.Column != 13 {
+		t.Errorf("ValidateRuleFile() recording rule name issue = %s, want position 4:13", issue)
+	}
+	if issue := validationErr.Issues[1]; issue.Line != 7 || issue.Column != 11 {
+		t.Errorf("ValidateRuleFile() expression issue = %s, want position 7:11", issue)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	names, err := MetricNames(`sum(job:requests:rate5m) / on(job) group_left count({__name__="up", job!=""}) + ignored{__name__=~"x.*"}`)
+	if err != nil {
+		t.Fatalf("MetricNames() error = %v", err)
+	}
+	want := []string{"ignored", "job:requests:rate5m", "up"}
+	if len(names) != len(want) {
+		t.Fatalf("MetricNames() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("MetricNames()[%d] = %s, want %s", i, names[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/VictoriaMetrics/victoriametrics-cloud-api-go/v1/exprcheck
+
+go 1.26
+
+require (
+	github.com/VictoriaMetrics/metricsql v0.84.6
+	github.com/VictoriaMetrics/victoriametrics-cloud-api-go v0.1.0
+)
+
+require (
+	github.com/VictoriaMetrics/metrics v1.35.3 // indirect
+	github.com/valyala/fastrand v1.1.0 // indirect
+	github.com/valyala/histogram v1.2.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.4 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+)
+
+// The package is developed together with the client library in the same repository.
+// Both modules are released together from the same commit: the client library is tagged vX.Y.Z
+// and this module is tagged v1/exprcheck/vX.Y.Z, requiring the client library of the same version.
+// Consumers ignore the replace directive and use the required version of the client library.
+replace github.com/VictoriaMetrics/victoriametrics-cloud-api-go => ../..
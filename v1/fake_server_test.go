@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCloud is an in-memory implementation of the subset of VictoriaMetrics Cloud API used by the client.
+// It is used by tests of helpers that perform multiple API calls.
+type fakeCloud struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	deployments map[string]DeploymentInfo
+	tokens      map[string][]AccessToken
+	ruleFiles   map[string]map[string]string
+	nextID      int
+	// failures maps "METHOD /path" to the status code that should be returned for the request
+	failures map[string]int
+	// requests contains all received requests in "METHOD /path" form
+	requests []string
+	// etags enables ETag and If-Match support for rule files
+	etags bool
+	// ifMatch contains If-Match headers of received requests
+	ifMatch []string
+}
+
+// newFakeCloud creates a fake VictoriaMetrics Cloud API server and a client connected to it
+func newFakeCloud(t *testing.T, opts ...VMCloudAPIClientOption) (*fakeCloud, *VMCloudAPIClient) {
+	f := &fakeCloud{
+		t:           t,
+		deployments: make(map[string]DeploymentInfo),
+		tokens:      make(map[string][]AccessToken),
+		ruleFiles:   make(map[string]map[string]string),
+		failures:    make(map[string]int),
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client, err := New("test-api-key", append([]VMCloudAPIClientOption{WithBaseURL(server.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return f, client
+}
+
+func (f *fakeCloud) addDeployment(d DeploymentInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deployments[d.ID] = d
+}
+
+func (f *fakeCloud) addToken(deploymentID string, token AccessToken) AccessToken {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if token.ID == "" {
+		f.nextID++
+		token.ID = fmt.Sprintf("token-%d", f.nextID)
+	}
+	if token.Secret == "" {
+		token.Secret = "secret-" + token.ID
+	}
+	f.tokens[deploymentID] = append(f.tokens[deploymentID], token)
+	return token
+}
+
+func (f *fakeCloud) addRuleFile(deploymentID, name, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ruleFiles[deploymentID] == nil {
+		f.ruleFiles[deploymentID] = make(map[string]string)
+	}
+	f.ruleFiles[deploymentID][name] = content
+}
+
+func (f *fakeCloud) ruleFile(deploymentID, name string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.ruleFiles[deploymentID][name]
+	return content, ok
+}
+
+func (f *fakeCloud) tokenList(deploymentID string) []AccessToken {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AccessToken(nil), f.tokens[deploymentID]...)
+}
+
+func (f *fakeCloud) failOn(method, path string, statusCode int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method+" "+path] = statusCode
+}
+
+func (f *fakeCloud) requestCount(method, path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, r := range f.requests {
+		if r == method+" "+path {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(AccessTokenHeader) != "test-api-key" {
+		f.t.Errorf("Request missing API key header")
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("Failed to read request body: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if v := r.Header.Get("If-Match"); v != "" {
+		f.ifMatch = append(f.ifMatch, v)
+	}
+	if code, ok := f.failures[r.Method+" "+r.URL.Path]; ok {
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte("injected failure"))
+		return
+	}
+
+	var segments []string
+	for _, s := range strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v1/deployments"), "/")[1:] {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil {
+			f.t.Errorf("Failed to unescape path segment %q: %v", s, err)
+		}
+		segments = append(segments, unescaped)
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/deployments") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		var list DeploymentSummaryList
+		for _, d := range f.deployments {
+			list = append(list, DeploymentSummary{
+				ID:            d.ID,
+				Name:          d.Name,
+				Type:          d.Type,
+				Tier:          d.Tier,
+				Version:       d.Version,
+				CloudProvider: d.CloudProvider,
+				Region:        d.Region,
+				CreatedAt:     d.CreatedAt,
+				Status:        d.Status,
+			})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+		f.writeJSON(w, list)
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		var req DeploymentCreationRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		d := DeploymentInfo{
+			ID:                fmt.Sprintf("00000000-0000-0000-0000-%012d", f.nextID),
+			Name:              req.Name,
+			Type:              req.Type,
+			Tier:              req.Tier,
+			CloudProvider:     req.Provider,
+			Region:            req.Region,
+			CreatedAt:         time.Now(),
+			Status:            DeploymentStatusProvisioning,
+			StorageSizeGb:     req.StorageSize,
+			MaintenanceWindow: req.MaintenanceWindow,
+		}
+		f.deployments[d.ID] = d
+		f.writeJSON(w, d)
+	case len(segments) == 1:
+		d, ok := f.deployments[segments[0]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			f.writeJSON(w, d)
+		case http.MethodDelete:
+			delete(f.deployments, d.ID)
+			delete(f.tokens, d.ID)
+			delete(f.ruleFiles, d.ID)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case len(segments) >= 2 && segments[1] == "access_tokens":
+		f.serveAccessTokens(w, r, body, segments)
+	case len(segments) >= 3 && segments[1] == "rule-sets" && segments[2] == "files":
+		f.serveRuleFiles(w, r, body, segments)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeCloud) serveAccessTokens(w http.ResponseWriter, r *http.Request, body []byte, segments []string) {
+	deploymentID := segments[0]
+	if _, ok := f.deployments[deploymentID]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(segments) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			list := AccessTokensList{}
+			for _, token := range f.tokens[deploymentID] {
+				// only the last 4 symbols of the secret are returned in the list
+				token.Secret = token.Secret[len(token.Secret)-4:]
+				list = append(list, token)
+			}
+			f.writeJSON(w, list)
+		case http.MethodPost:
+			var req AccessTokenCreateRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			f.nextID++
+			token := AccessToken{
+				ID:          fmt.Sprintf("token-%d", f.nextID),
+				Secret:      fmt.Sprintf("secret-token-%d", f.nextID),
+				Type:        req.Type,
+				Description: req.Description,
+				TenantID:    req.TenantID,
+				CreatedBy:   "test-user",
+				CreatedAt:   time.Now(),
+			}
+			f.tokens[deploymentID] = append(f.tokens[deploymentID], token)
+			f.writeJSON(w, token)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	tokens := f.tokens[deploymentID]
+	for i, token := range tokens {
+		if token.ID != segments[2] {
+			continue
+		}
+		switch r.Method {
+		case http.MethodGet:
+			f.writeJSON(w, token)
+		case http.MethodDelete:
+			f.tokens[deploymentID] = append(tokens[:i:i], tokens[i+1:]...)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (f *fakeCloud) serveRuleFiles(w http.ResponseWriter, r *http.Request, body []byte, segments []string) {
+	deploymentID := segments[0]
+	if _, ok := f.deployments[deploymentID]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	files := f.ruleFiles[deploymentID]
+	if len(segments) == 3 {
+		names := []string{}
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		f.writeJSON(w, names)
+		return
+	}
+	name := segments[3]
+	switch r.Method {
+	case http.MethodGet:
+		content, ok := files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if f.etags {
+			w.Header().Set("ETag", fakeETag(content))
+		}
+		_, _ = w.Write([]byte(content))
+	case http.MethodPost:
+		if ifMatch := r.Header.Get("If-Match"); f.etags && ifMatch != "" {
+			if content, ok := files[name]; !ok || fakeETag(content) != ifMatch {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		if files == nil {
+			files = make(map[string]string)
+			f.ruleFiles[deploymentID] = files
+		}
+		files[name] = string(body)
+	case http.MethodDelete:
+		if _, ok := files[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(files, name)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func fakeETag(content string) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(content)))
+}
+
+func (f *fakeCloud) writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		f.t.Errorf("Failed to marshal response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GrafanaDatasourceType - type of the Grafana datasource
+type GrafanaDatasourceType string
+
+const (
+	// GrafanaDatasourcePrometheus - built-in Prometheus datasource of Grafana
+	GrafanaDatasourcePrometheus GrafanaDatasourceType = "prometheus"
+	// GrafanaDatasourceVictoriaMetrics - VictoriaMetrics datasource plugin of Grafana
+	// See https://github.com/VictoriaMetrics/victoriametrics-datasource
+	GrafanaDatasourceVictoriaMetrics GrafanaDatasourceType = "victoriametrics-metrics-datasource"
+)
+
+func (t GrafanaDatasourceType) String() string {
+	return string(t)
+}
+
+// GrafanaDatasourceTarget - deployment and access token used by the Grafana datasource
+type GrafanaDatasourceTarget struct {
+	// Deployment - deployment queried by the datasource
+	Deployment DeploymentInfo
+	// Token - revealed access token allowing reading data. For cluster deployments the datasource
+	// queries the tenant of the token, tenant 0:0 is used if the token has no tenant.
+	Token AccessToken
+	// Name - name of the datasource, derived from the deployment name and tenant if empty
+	Name string
+	// IsDefault marks the datasource as the default one in Grafana
+	IsDefault bool
+}
+
+// GrafanaDatasourcesOptions - options for GrafanaDatasourcesYAML
+type GrafanaDatasourcesOptions struct {
+	// Type - type of datasources, GrafanaDatasourcePrometheus is used if empty
+	Type GrafanaDatasourceType
+	// Editable allows editing provisioned datasources in Grafana UI
+	Editable bool
+}
+
+// GrafanaDatasourceUID returns the stable UID of the Grafana datasource of the deployment and tenant.
+// The tenant is ignored for single-node deployments.
+func GrafanaDatasourceUID(deployment DeploymentInfo, tenant TenantID) string {
+	key := deployment.ID
+	if deployment.Type == DeploymentTypeCluster {
+		key += "/" + tenant.String()
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "vmcloud-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// GrafanaDatasourcesYAML returns the Grafana datasource provisioning file with a datasource for every target.
+// Access tokens are passed in the Authorization header stored in secureJsonData, so the result contains
+// secrets of access tokens and must be stored accordingly.
+func GrafanaDatasourcesYAML(targets []GrafanaDatasourceTarget, opts GrafanaDatasourcesOptions) ([]byte, error) {
+	if opts.Type == "" {
+		opts.Type = GrafanaDatasourcePrometheus
+	}
+	if opts.Type != GrafanaDatasourcePrometheus && opts.Type != GrafanaDatasourceVictoriaMetrics {
+		return nil, fmt.Errorf("unsupported Grafana datasource type %q, expected %q or %q", opts.Type, GrafanaDatasourcePrometheus, GrafanaDatasourceVictoriaMetrics)
+	}
+
+	type datasource struct {
+		Name           string            `yaml:"name"`
+		UID            string            `yaml:"uid"`
+		Type           string            `yaml:"type"`
+		Access         string            `yaml:"access"`
+		URL            string            `yaml:"url"`
+		IsDefault      bool              `yaml:"isDefault"`
+		Editable       bool              `yaml:"editable"`
+		JSONData       map[string]string `yaml:"jsonData"`
+		SecureJSONData map[string]string `yaml:"secureJsonData"`
+	}
+	datasources := make([]datasource, 0, len(targets))
+	names := make(map[string]bool, len(targets))
+	uids := make(map[string]string, len(targets))
+	hasDefault := false
+	for _, target := range targets {
+		name, uid, url, err := grafanaDatasourceTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate Grafana datasource name %q", name)
+		}
+		names[name] = true
+		if previous, ok := uids[uid]; ok {
+			return nil, fmt.Errorf("datasources %q and %q of Grafana query the same deployment %q and tenant", previous, name, target.Deployment.ID)
+		}
+		uids[uid] = name
+		if target.IsDefault {
+			if hasDefault {
+				return nil, fmt.Errorf("only one Grafana datasource can be the default one, %q is the second", name)
+			}
+			hasDefault = true
+		}
+		datasources = append(datasources, datasource{
+			Name:           name,
+			UID:            uid,
+			Type:           opts.Type.String(),
+			Access:         "proxy",
+			URL:            url,
+			IsDefault:      target.IsDefault,
+			Editable:       opts.Editable,
+			JSONData:       map[string]string{"httpHeaderName1": "Authorization"},
+			SecureJSONData: map[string]string{"httpHeaderValue1": "Bearer " + target.Token.Secret},
+		})
+	}
+	cfg := struct {
+		APIVersion  int          `yaml:"apiVersion"`
+		Datasources []datasource `yaml:"datasources"`
+	}{
+		APIVersion:  1,
+		Datasources: datasources,
+	}
+	return marshalConfigYAML(cfg, "Grafana datasources")
+}
+
+// grafanaDatasourceTarget returns the name, UID and URL of the datasource for the target
+func grafanaDatasourceTarget(target GrafanaDatasourceTarget) (name, uid, url string, err error) {
+	d, token := target.Deployment, target.Token
+	if token.Type != AccessModeRead && token.Type != AccessModeReadWrite {
+		return "", "", "", fmt.Errorf("access token %q with access mode %q doesn't allow reading data", token.ID, token.Type)
+	}
+	if token.Secret == "" {
+		return "", "", "", fmt.Errorf("access token %q has no secret, it must be revealed first", token.ID)
+	}
+	name = target.Name
+	if name == "" {
+		name = d.Name
+		if name == "" {
+			name = d.ID
+		}
+	}
+	switch d.Type {
+	case DeploymentTypeSingleNode:
+		endpoint, err := d.accessEndpointURL()
+		if err != nil {
+			return "", "", "", err
+		}
+		url = endpoint.String()
+		uid = GrafanaDatasourceUID(d, TenantID{})
+	case DeploymentTypeCluster:
+		tenant, err := token.tenant()
+		if err != nil {
+			return "", "", "", err
+		}
+		if url, err = d.SelectURL(tenant, "prometheus"); err != nil {
+			return "", "", "", err
+		}
+		uid = GrafanaDatasourceUID(d, tenant)
+		if target.Name == "" {
+			name += " (tenant " + tenant.String() + ")"
+		}
+	default:
+		return "", "", "", fmt.Errorf("unsupported type %q of deployment %q", d.Type, d.ID)
+	}
+	return name, uid, url, nil
+}
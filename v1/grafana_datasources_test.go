@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrafanaDatasourcesYAML(t *testing.T) {
+	single := DeploymentInfo{ID: "d1", Name: "prod", Type: DeploymentTypeSingleNode, AccessEndpoint: "https://single.example.com"}
+	cluster := DeploymentInfo{ID: "d2", Name: "shared", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com"}
+	targets := []GrafanaDatasourceTarget{
+		{Deployment: single, Token: AccessToken{ID: "t1", Type: AccessModeRead, Secret: "s1"}, IsDefault: true},
+		{Deployment: cluster, Token: AccessToken{ID: "t2", Type: AccessModeReadWrite, TenantID: "12", Secret: "s2"}},
+	}
+
+	got, err := GrafanaDatasourcesYAML(targets, GrafanaDatasourcesOptions{Type: GrafanaDatasourceVictoriaMetrics})
+	if err != nil {
+		t.Fatalf("GrafanaDatasourcesYAML() error = %v", err)
+	}
+	want := `apiVersion: 1
+datasources:
+  - name: prod
+    uid: ` + GrafanaDatasourceUID(single, TenantID{}) + `
+    type: victoriametrics-metrics-datasource
+    access: proxy
+    url: https://single.example.com
+    isDefault: true
+    editable: false
+    jsonData:
+      httpHeaderName1: Authorization
+    secureJsonData:
+      httpHeaderValue1: Bearer s1
+  - name: shared (tenant 12:0)
+    uid: ` + GrafanaDatasourceUID(cluster, NewTenantID(12, 0)) + `
+    type: victoriametrics-metrics-datasource
+    access: proxy
+    url: https://cluster.example.com/select/12:0/prometheus
+    isDefault: false
+    editable: false
+    jsonData:
+      httpHeaderName1: Authorization
+    secureJsonData:
+      httpHeaderValue1: Bearer s2
+`
+	if string(got) != want {
+		t.Errorf("GrafanaDatasourcesYAML() = %s, want %s", got, want)
+	}
+
+	got, err = GrafanaDatasourcesYAML(targets[:1], GrafanaDatasourcesOptions{})
+	if err != nil {
+		t.Fatalf("GrafanaDatasourcesYAML() error = %v", err)
+	}
+	if !strings.Contains(string(got), "type: prometheus\n") {
+		t.Errorf("GrafanaDatasourcesYAML() with default type = %s, want prometheus datasource", got)
+	}
+}
+
+func TestGrafanaDatasourcesYAML_Errors(t *testing.T) {
+	single := DeploymentInfo{ID: "d1", Type: DeploymentTypeSingleNode, AccessEndpoint: "https://single.example.com"}
+	cluster := DeploymentInfo{ID: "d2", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com"}
+	readToken := AccessToken{ID: "t1", Type: AccessModeRead, Secret: "s1"}
+	tests := []struct {
+		name    string
+		targets []GrafanaDatasourceTarget
+		opts    GrafanaDatasourcesOptions
+	}{
+		{
+			name:    "unsupported type",
+			targets: []GrafanaDatasourceTarget{{Deployment: single, Token: readToken}},
+			opts:    GrafanaDatasourcesOptions{Type: "loki"},
+		},
+		{
+			name:    "write-only token",
+			targets: []GrafanaDatasourceTarget{{Deployment: single, Token: AccessToken{ID: "t2", Type: AccessModeWrite, Secret: "s2"}}},
+		},
+		{
+			name:    "token without secret",
+			targets: []GrafanaDatasourceTarget{{Deployment: single, Token: AccessToken{ID: "t3", Type: AccessModeRead}}},
+		},
+		{
+			name: "same deployment and tenant",
+			targets: []GrafanaDatasourceTarget{
+				{Deployment: cluster, Token: AccessToken{ID: "t4", Type: AccessModeRead, TenantID: "1", Secret: "s4"}, Name: "a"},
+				{Deployment: cluster, Token: AccessToken{ID: "t5", Type: AccessModeRead, TenantID: "1:0", Secret: "s5"}, Name: "b"},
+			},
+		},
+		{
+			name: "duplicate name",
+			targets: []GrafanaDatasourceTarget{
+				{Deployment: single, Token: readToken, Name: "metrics"},
+				{Deployment: cluster, Token: readToken, Name: "metrics"},
+			},
+		},
+		{
+			name: "two defaults",
+			targets: []GrafanaDatasourceTarget{
+				{Deployment: single, Token: readToken, IsDefault: true},
+				{Deployment: cluster, Token: readToken, IsDefault: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GrafanaDatasourcesYAML(tt.targets, tt.opts); err == nil {
+				t.Errorf("GrafanaDatasourcesYAML() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestGrafanaDatasourceUID(t *testing.T) {
+	cluster := DeploymentInfo{ID: "d2", Type: DeploymentTypeCluster}
+	uid := GrafanaDatasourceUID(cluster, NewTenantID(1, 0))
+	if uid != GrafanaDatasourceUID(cluster, NewTenantID(1, 0)) {
+		t.Errorf("GrafanaDatasourceUID() is not stable")
+	}
+	if uid == GrafanaDatasourceUID(cluster, NewTenantID(2, 0)) {
+		t.Errorf("GrafanaDatasourceUID() is the same for different tenants")
+	}
+	if len(uid) > 40 {
+		t.Errorf("GrafanaDatasourceUID() = %q is longer than 40 characters allowed by Grafana", uid)
+	}
+	single := DeploymentInfo{ID: "d1", Type: DeploymentTypeSingleNode}
+	if GrafanaDatasourceUID(single, NewTenantID(1, 0)) != GrafanaDatasourceUID(single, TenantID{}) {
+		t.Errorf("GrafanaDatasourceUID() depends on the tenant for single-node deployment")
+	}
+}
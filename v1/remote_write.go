@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const defaultConfigComponentName = "vmcloud"
+
+var invalidConfigComponentNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// RemoteWriteConfig - data ingestion settings of the deployment for the access token.
+// The secret of the access token is never included, agents read it from BearerTokenFile
+// (see WriteAccessTokenSecretFile).
+type RemoteWriteConfig struct {
+	// Name - name of the remote write target and OpenTelemetry Collector components, derived from the deployment name
+	Name string
+	// URL - Prometheus remote write URL of the deployment
+	URL string
+	// OpenTelemetryURL - base URL of the OpenTelemetry (OTLP/HTTP) ingestion API of the deployment
+	OpenTelemetryURL string
+	// BearerTokenFile - path of the file containing the secret of the access token
+	BearerTokenFile string
+}
+
+// NewRemoteWriteConfig returns data ingestion settings of the deployment for the access token with the secret
+// stored in bearerTokenFile. The access token must allow writing. For cluster deployments URLs contain
+// the tenant of the access token, tenant 0:0 is used if the token has no tenant.
+func NewRemoteWriteConfig(deployment DeploymentInfo, token AccessToken, bearerTokenFile string) (RemoteWriteConfig, error) {
+	if token.Type != AccessModeWrite && token.Type != AccessModeReadWrite {
+		return RemoteWriteConfig{}, fmt.Errorf("access token %q with access mode %q doesn't allow writing data", token.ID, token.Type)
+	}
+	if bearerTokenFile == "" {
+		return RemoteWriteConfig{}, fmt.Errorf("path of the secret file of access token %q cannot be empty", token.ID)
+	}
+	cfg := RemoteWriteConfig{
+		Name:            configComponentName(deployment),
+		BearerTokenFile: bearerTokenFile,
+	}
+	switch deployment.Type {
+	case DeploymentTypeSingleNode:
+		endpoint, err := deployment.accessEndpointURL()
+		if err != nil {
+			return RemoteWriteConfig{}, err
+		}
+		cfg.URL = endpoint.JoinPath("api/v1/write").String()
+		cfg.OpenTelemetryURL = endpoint.JoinPath("opentelemetry").String()
+	case DeploymentTypeCluster:
+		tenant, err := token.tenant()
+		if err != nil {
+			return RemoteWriteConfig{}, err
+		}
+		if cfg.URL, err = deployment.InsertURL(tenant, "prometheus/api/v1/write"); err != nil {
+			return RemoteWriteConfig{}, err
+		}
+		if cfg.OpenTelemetryURL, err = deployment.InsertURL(tenant, "opentelemetry"); err != nil {
+			return RemoteWriteConfig{}, err
+		}
+	default:
+		return RemoteWriteConfig{}, fmt.Errorf("unsupported type %q of deployment %q", deployment.Type, deployment.ID)
+	}
+	return cfg, nil
+}
+
+// VMAgentFlags returns vmagent command-line flags for writing data to the deployment
+func (c RemoteWriteConfig) VMAgentFlags() []string {
+	return []string{
+		"-remoteWrite.url=" + c.URL,
+		"-remoteWrite.bearerTokenFile=" + c.BearerTokenFile,
+	}
+}
+
+// PrometheusYAML returns the Prometheus remote_write configuration section for writing data to the deployment
+func (c RemoteWriteConfig) PrometheusYAML() ([]byte, error) {
+	type authorization struct {
+		Type            string `yaml:"type"`
+		CredentialsFile string `yaml:"credentials_file"`
+	}
+	type remoteWrite struct {
+		Name          string        `yaml:"name"`
+		URL           string        `yaml:"url"`
+		Authorization authorization `yaml:"authorization"`
+	}
+	cfg := struct {
+		RemoteWrite []remoteWrite `yaml:"remote_write"`
+	}{
+		RemoteWrite: []remoteWrite{{
+			Name:          c.Name,
+			URL:           c.URL,
+			Authorization: authorization{Type: "Bearer", CredentialsFile: c.BearerTokenFile},
+		}},
+	}
+	return marshalConfigYAML(cfg, "Prometheus remote_write configuration")
+}
+
+// OpenTelemetryCollectorYAML returns the OpenTelemetry Collector configuration with the otlphttp exporter
+// and the bearertokenauth extension for writing data to the deployment. The exporter
+// otlphttp/<Name> must be added to metrics pipelines of the collector.
+func (c RemoteWriteConfig) OpenTelemetryCollectorYAML() ([]byte, error) {
+	type auth struct {
+		Authenticator string `yaml:"authenticator"`
+	}
+	type exporter struct {
+		Endpoint string `yaml:"endpoint"`
+		Auth     auth   `yaml:"auth"`
+	}
+	type extension struct {
+		Filename string `yaml:"filename"`
+	}
+	type service struct {
+		Extensions []string `yaml:"extensions"`
+	}
+	authName := "bearertokenauth/" + c.Name
+	cfg := struct {
+		Extensions map[string]extension `yaml:"extensions"`
+		Exporters  map[string]exporter  `yaml:"exporters"`
+		Service    service              `yaml:"service"`
+	}{
+		Extensions: map[string]extension{authName: {Filename: c.BearerTokenFile}},
+		Exporters: map[string]exporter{"otlphttp/" + c.Name: {
+			Endpoint: c.OpenTelemetryURL,
+			Auth:     auth{Authenticator: authName},
+		}},
+		Service: service{Extensions: []string{authName}},
+	}
+	return marshalConfigYAML(cfg, "OpenTelemetry Collector configuration")
+}
+
+// WriteAccessTokenSecretFile writes the secret of the revealed access token to the file readable only by its owner,
+// so it can be referenced by RemoteWriteConfig.BearerTokenFile. The secret is written to a temporary file
+// in the same directory, which then replaces the existing file, so permissions of the existing file are not reused
+// and readers never see a partially written secret.
+func WriteAccessTokenSecretFile(path string, token AccessToken) error {
+	if token.Secret == "" {
+		return fmt.Errorf("access token %q has no secret, it must be revealed first", token.ID)
+	}
+	// os.CreateTemp creates the file with 0600 permissions
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write secret of access token %q: %w", token.ID, err)
+	}
+	_, err = f.WriteString(token.Secret)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write secret of access token %q: %w", token.ID, err)
+	}
+	return nil
+}
+
+// configComponentName converts the deployment name to the name usable in agent configurations
+func configComponentName(deployment DeploymentInfo) string {
+	name := invalidConfigComponentNameChars.ReplaceAllString(strings.ToLower(deployment.Name), "-")
+	name = strings.Trim(name, "_-")
+	if name == "" {
+		return defaultConfigComponentName
+	}
+	return name
+}
+
+func marshalConfigYAML(v any, what string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", what, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", what, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewRemoteWriteConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployment DeploymentInfo
+		token      AccessToken
+		wantURL    string
+		wantOTel   string
+		wantName   string
+		wantErr    bool
+	}{
+		{
+			name:       "single-node",
+			deployment: DeploymentInfo{ID: "d1", Name: "Prod Metrics", Type: DeploymentTypeSingleNode, AccessEndpoint: "https://single.example.com"},
+			token:      AccessToken{ID: "t1", Type: AccessModeWrite},
+			wantURL:    "https://single.example.com/api/v1/write",
+			wantOTel:   "https://single.example.com/opentelemetry",
+			wantName:   "prod-metrics",
+		},
+		{
+			name:       "cluster with tenant",
+			deployment: DeploymentInfo{ID: "d2", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com/"},
+			token:      AccessToken{ID: "t2", Type: AccessModeReadWrite, TenantID: "12"},
+			wantURL:    "https://cluster.example.com/insert/12:0/prometheus/api/v1/write",
+			wantOTel:   "https://cluster.example.com/insert/12:0/opentelemetry",
+			wantName:   "vmcloud",
+		},
+		{
+			name:       "cluster without tenant",
+			deployment: DeploymentInfo{ID: "d2", Name: "eu", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com"},
+			token:      AccessToken{ID: "t3", Type: AccessModeWrite},
+			wantURL:    "https://cluster.example.com/insert/0:0/prometheus/api/v1/write",
+			wantOTel:   "https://cluster.example.com/insert/0:0/opentelemetry",
+			wantName:   "eu",
+		},
+		{
+			name:       "read-only token",
+			deployment: DeploymentInfo{ID: "d1", Type: DeploymentTypeSingleNode, AccessEndpoint: "https://single.example.com"},
+			token:      AccessToken{ID: "t4", Type: AccessModeRead},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid tenant",
+			deployment: DeploymentInfo{ID: "d2", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com"},
+			token:      AccessToken{ID: "t5", Type: AccessModeWrite, TenantID: "a:b"},
+			wantErr:    true,
+		},
+		{
+			name:       "no access endpoint",
+			deployment: DeploymentInfo{ID: "d1", Type: DeploymentTypeSingleNode},
+			token:      AccessToken{ID: "t6", Type: AccessModeWrite},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewRemoteWriteConfig(tt.deployment, tt.token, "/etc/vmcloud/token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRemoteWriteConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cfg.URL != tt.wantURL || cfg.OpenTelemetryURL != tt.wantOTel || cfg.Name != tt.wantName {
+				t.Errorf("NewRemoteWriteConfig() = %+v, want URL %q, OpenTelemetry URL %q, name %q", cfg, tt.wantURL, tt.wantOTel, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestRemoteWriteConfigOutputs(t *testing.T) {
+	deployment := DeploymentInfo{ID: "d1", Name: "prod", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com"}
+	token := AccessToken{ID: "t1", Type: AccessModeWrite, TenantID: "1:2", Secret: "top-secret"}
+	cfg, err := NewRemoteWriteConfig(deployment, token, "/etc/vmcloud/token")
+	if err != nil {
+		t.Fatalf("NewRemoteWriteConfig() error = %v", err)
+	}
+
+	wantFlags := []string{
+		"-remoteWrite.url=https://cluster.example.com/insert/1:2/prometheus/api/v1/write",
+		"-remoteWrite.bearerTokenFile=/etc/vmcloud/token",
+	}
+	if got := cfg.VMAgentFlags(); !reflect.DeepEqual(got, wantFlags) {
+		t.Errorf("VMAgentFlags() = %v, want %v", got, wantFlags)
+	}
+
+	prometheus, err := cfg.PrometheusYAML()
+	if err != nil {
+		t.Fatalf("PrometheusYAML() error = %v", err)
+	}
+	wantPrometheus := `remote_write:
+  - name: prod
+    url: https://cluster.example.com/insert/1:2/prometheus/api/v1/write
+    authorization:
+      type: Bearer
+      credentials_file: /etc/vmcloud/token
+`
+	if string(prometheus) != wantPrometheus {
+		t.Errorf("PrometheusYAML() = %s, want %s", prometheus, wantPrometheus)
+	}
+
+	otel, err := cfg.OpenTelemetryCollectorYAML()
+	if err != nil {
+		t.Fatalf("OpenTelemetryCollectorYAML() error = %v", err)
+	}
+	wantOTel := `extensions:
+  bearertokenauth/prod:
+    filename: /etc/vmcloud/token
+exporters:
+  otlphttp/prod:
+    endpoint: https://cluster.example.com/insert/1:2/opentelemetry
+    auth:
+      authenticator: bearertokenauth/prod
+service:
+  extensions:
+    - bearertokenauth/prod
+`
+	if string(otel) != wantOTel {
+		t.Errorf("OpenTelemetryCollectorYAML() = %s, want %s", otel, wantOTel)
+	}
+
+	for _, out := range []string{strings.Join(cfg.VMAgentFlags(), " "), string(prometheus), string(otel)} {
+		if strings.Contains(out, token.Secret) {
+			t.Errorf("generated configuration contains the secret: %s", out)
+		}
+	}
+}
+
+func TestWriteAccessTokenSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := WriteAccessTokenSecretFile(path, AccessToken{ID: "t1"}); err == nil {
+		t.Errorf("WriteAccessTokenSecretFile() without secret error = nil, want error")
+	}
+	if err := WriteAccessTokenSecretFile(path, AccessToken{ID: "t1", Secret: "top-secret"}); err != nil {
+		t.Fatalf("WriteAccessTokenSecretFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read secret file: %v", err)
+	}
+	if string(data) != "top-secret" {
+		t.Errorf("secret file content = %q, want %q", data, "top-secret")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat secret file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("secret file permissions = %o, want 600", perm)
+	}
+
+	// permissions of the existing world-readable file are not reused
+	existing := filepath.Join(t.TempDir(), "existing")
+	if err := os.WriteFile(existing, []byte("old-secret"), 0o644); err != nil {
+		t.Fatalf("failed to create existing file: %v", err)
+	}
+	if err := WriteAccessTokenSecretFile(existing, AccessToken{ID: "t1", Secret: "new-secret"}); err != nil {
+		t.Fatalf("WriteAccessTokenSecretFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "new-secret" {
+		t.Errorf("secret file content = %q, want %q", data, "new-secret")
+	}
+	info, err = os.Stat(existing)
+	if err != nil {
+		t.Fatalf("failed to stat secret file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("existing secret file permissions = %o, want 600", perm)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(existing)); len(entries) != 1 {
+		t.Errorf("temporary files are left in the directory: %v", entries)
+	}
+}
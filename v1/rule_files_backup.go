@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RuleFilesBackupVersion - version of the rule files backup format written by BackupRuleFiles
+	RuleFilesBackupVersion = 1
+
+	ruleFilesBackupManifestName = "manifest.json"
+	ruleFilesBackupDir          = "rules"
+	// maxRuleFilesBackupEntrySize limits the size of a single archive entry read by RestoreRuleFiles
+	maxRuleFilesBackupEntrySize = 32 << 20
+)
+
+// RuleFileBackupEntry - rule file stored in the backup
+type RuleFileBackupEntry struct {
+	// Name - name of the rule file
+	Name string `json:"name"`
+	// SHA256 - hex-encoded SHA-256 hash of the rule file content
+	SHA256 string `json:"sha256"`
+	// Size - size of the rule file content in bytes
+	Size int64 `json:"size"`
+}
+
+// RuleFilesBackupManifest - manifest of the rule files backup stored as manifest.json in the archive
+type RuleFilesBackupManifest struct {
+	// Version - version of the backup format
+	Version int `json:"version"`
+	// CreatedAt - time of the backup creation
+	CreatedAt time.Time `json:"created_at"`
+	// DeploymentID - identifier of the backed up deployment
+	DeploymentID string `json:"deployment_id"`
+	// Files - rule files sorted by name
+	Files []RuleFileBackupEntry `json:"files"`
+}
+
+// RestoreRuleFilesOptions - options for RestoreRuleFiles
+type RestoreRuleFilesOptions struct {
+	// Files - names of rule files to restore, all rule files of the backup are restored if empty
+	Files []string
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+}
+
+// RestoreRuleFilesReport - result of RestoreRuleFiles
+type RestoreRuleFilesReport struct {
+	// DryRun is true if no changes have been made
+	DryRun bool `json:"dry_run"`
+	// Manifest - manifest of the restored backup
+	Manifest RuleFilesBackupManifest `json:"manifest"`
+	// Files - per-file results sorted by file name
+	Files []RuleFileResult `json:"files"`
+}
+
+// BackupRuleFiles writes all alerting/recording rule files of the deployment into w as a tar.gz archive.
+// The archive contains manifest.json with hashes of the files and rule files in the rules/ directory.
+// It fails before writing anything if some rule file name cannot be stored in the archive.
+func (a *VMCloudAPIClient) BackupRuleFiles(ctx context.Context, deploymentID string, w io.Writer) (RuleFilesBackupManifest, error) {
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return RuleFilesBackupManifest{}, err
+	}
+	names, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return RuleFilesBackupManifest{}, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkRuleFileBackupName(name); err != nil {
+			return RuleFilesBackupManifest{}, fmt.Errorf("rule file of deployment %q cannot be backed up: %w", deploymentID, err)
+		}
+	}
+
+	manifest := RuleFilesBackupManifest{
+		Version:      RuleFilesBackupVersion,
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+		DeploymentID: deploymentID,
+		Files:        make([]RuleFileBackupEntry, 0, len(names)),
+	}
+	contents := make([]string, 0, len(names))
+	for _, name := range names {
+		content, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, name)
+		if err != nil {
+			return RuleFilesBackupManifest{}, fmt.Errorf("failed to get rule file %q of deployment %q: %w", name, deploymentID, err)
+		}
+		sum := sha256.Sum256([]byte(content))
+		manifest.Files = append(manifest.Files, RuleFileBackupEntry{
+			Name:   name,
+			SHA256: hex.EncodeToString(sum[:]),
+			Size:   int64(len(content)),
+		})
+		contents = append(contents, content)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return RuleFilesBackupManifest{}, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := writeTarFile(tw, ruleFilesBackupManifestName, append(manifestData, '\n'), manifest.CreatedAt); err != nil {
+		return RuleFilesBackupManifest{}, err
+	}
+	for i, entry := range manifest.Files {
+		if err := writeTarFile(tw, path.Join(ruleFilesBackupDir, entry.Name), []byte(contents[i]), manifest.CreatedAt); err != nil {
+			return RuleFilesBackupManifest{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return RuleFilesBackupManifest{}, fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return RuleFilesBackupManifest{}, fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// checkRuleFileBackupName checks that the rule file name can be stored in the backup archive.
+// The same check is applied by BackupRuleFiles, ReadRuleFilesBackup and RestoreRuleFiles,
+// so every backup written by BackupRuleFiles can be restored.
+func checkRuleFileBackupName(name string) error {
+	if err := checkPathParam("rule file name", name); err != nil {
+		return err
+	}
+	if strings.Contains(name, "/") {
+		return &ValidationError{Field: "rule file name", Value: name, Reason: "cannot contain slashes"}
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s to backup archive: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to backup archive: %w", name, err)
+	}
+	return nil
+}
+
+// RestoreRuleFiles restores alerting/recording rule files of the deployment from the tar.gz archive created by BackupRuleFiles.
+// The archive may be created for a different deployment. The whole archive is verified against the manifest
+// before any changes are made. Restored files replace existing rule files with the same name,
+// rule files missing in the backup are left as is. Rule files are restored exactly as backed up,
+// without validation of names and contents, so backups of legacy rule files can always be restored.
+// On error, the returned report contains the files processed before the failure.
+func (a *VMCloudAPIClient) RestoreRuleFiles(ctx context.Context, deploymentID string, r io.Reader, opts RestoreRuleFilesOptions) (RestoreRuleFilesReport, error) {
+	report := RestoreRuleFilesReport{DryRun: opts.DryRun}
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return report, err
+	}
+	manifest, files, err := ReadRuleFilesBackup(r)
+	if err != nil {
+		return report, err
+	}
+	report.Manifest = manifest
+
+	names := make([]string, 0, len(files))
+	if len(opts.Files) == 0 {
+		for name := range files {
+			names = append(names, name)
+		}
+	} else {
+		for _, name := range opts.Files {
+			if _, ok := files[name]; !ok {
+				return report, fmt.Errorf("rule file %q is not found in the backup", name)
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkRuleFileBackupName(name); err != nil {
+			return report, err
+		}
+	}
+
+	remoteNames, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return report, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	for _, name := range names {
+		content := files[name]
+		action := RuleFileActionCreated
+		if slices.Contains(remoteNames, name) {
+			remoteContent, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, name)
+			if err != nil {
+				return report, fmt.Errorf("failed to get rule file %q of deployment %q: %w", name, deploymentID, err)
+			}
+			action = RuleFileActionUpdated
+			if remoteContent == content {
+				action = RuleFileActionUnchanged
+			}
+		}
+		if action != RuleFileActionUnchanged && !opts.DryRun {
+			if err := a.uploadDeploymentRuleFile(ctx, deploymentID, name, content); err != nil {
+				return report, fmt.Errorf("failed to restore rule file %q for deployment %q: %w", name, deploymentID, err)
+			}
+		}
+		report.Files = append(report.Files, RuleFileResult{Name: name, Action: action})
+	}
+	return report, nil
+}
+
+// ReadRuleFilesBackup reads the tar.gz archive created by BackupRuleFiles and verifies rule files against the manifest.
+// It returns the manifest and the content of rule files by name.
+func ReadRuleFilesBackup(r io.Reader) (RuleFilesBackupManifest, map[string]string, error) {
+	var manifest RuleFilesBackupManifest
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	defer func() {
+		_ = gr.Close()
+	}()
+	tr := tar.NewReader(gr)
+	var hasManifest bool
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return manifest, nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return manifest, nil, fmt.Errorf("unexpected entry %q of type %q in backup archive", hdr.Name, hdr.Typeflag)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxRuleFilesBackupEntrySize+1))
+		if err != nil {
+			return manifest, nil, fmt.Errorf("failed to read %s from backup archive: %w", hdr.Name, err)
+		}
+		if len(data) > maxRuleFilesBackupEntrySize {
+			return manifest, nil, fmt.Errorf("entry %q of backup archive exceeds %d bytes", hdr.Name, maxRuleFilesBackupEntrySize)
+		}
+		switch dir, name := path.Split(path.Clean(hdr.Name)); {
+		case hdr.Name == ruleFilesBackupManifestName:
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return manifest, nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+			}
+			hasManifest = true
+		case dir == ruleFilesBackupDir+"/" && checkRuleFileBackupName(name) == nil:
+			files[name] = string(data)
+		default:
+			return manifest, nil, fmt.Errorf("unexpected entry %q in backup archive", hdr.Name)
+		}
+	}
+	if !hasManifest {
+		return manifest, nil, fmt.Errorf("backup archive doesn't contain %s", ruleFilesBackupManifestName)
+	}
+	if manifest.Version != RuleFilesBackupVersion {
+		return manifest, nil, fmt.Errorf("unsupported backup version %d, expected %d", manifest.Version, RuleFilesBackupVersion)
+	}
+	if len(manifest.Files) != len(files) {
+		return manifest, nil, fmt.Errorf("backup manifest lists %d rule files, archive contains %d", len(manifest.Files), len(files))
+	}
+	for _, entry := range manifest.Files {
+		content, ok := files[entry.Name]
+		if !ok {
+			return manifest, nil, fmt.Errorf("rule file %q listed in backup manifest is missing in the archive", entry.Name)
+		}
+		sum := sha256.Sum256([]byte(content))
+		if int64(len(content)) != entry.Size || hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return manifest, nil, fmt.Errorf("rule file %q doesn't match the hash in backup manifest", entry.Name)
+		}
+	}
+	return manifest, files, nil
+}
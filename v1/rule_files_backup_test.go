@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestoreRuleFiles(t *testing.T) {
+	source := "123e4567-e89b-12d3-a456-426614174000"
+	target := "223e4567-e89b-12d3-a456-426614174001"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: source})
+	fake.addDeployment(DeploymentInfo{ID: target})
+	fake.addRuleFile(source, "b.yml", "groups: []\n")
+	fake.addRuleFile(source, "a.yml", "groups:\n- name: a\n  rules: []\n")
+	fake.addRuleFile(target, "a.yml", "groups: []\n")
+	fake.addRuleFile(target, "b.yml", "groups: []\n")
+	fake.addRuleFile(target, "other.yml", "groups: []\n")
+
+	var buf bytes.Buffer
+	manifest, err := client.BackupRuleFiles(context.Background(), source, &buf)
+	if err != nil {
+		t.Fatalf("BackupRuleFiles() error = %v", err)
+	}
+	if manifest.DeploymentID != source || len(manifest.Files) != 2 || manifest.Files[0].Name != "a.yml" || manifest.CreatedAt.IsZero() {
+		t.Fatalf("BackupRuleFiles() manifest = %+v", manifest)
+	}
+	sum := sha256.Sum256([]byte("groups: []\n"))
+	if entry := manifest.Files[1]; entry.SHA256 != hex.EncodeToString(sum[:]) || entry.Size != 11 {
+		t.Errorf("BackupRuleFiles() entry = %+v", entry)
+	}
+	archive := buf.Bytes()
+
+	// accidentally deleted rule file is restored on the same deployment
+	if err := client.DeleteDeploymentRuleFile(context.Background(), source, "a.yml"); err != nil {
+		t.Fatalf("DeleteDeploymentRuleFile() error = %v", err)
+	}
+	report, err := client.RestoreRuleFiles(context.Background(), source, bytes.NewReader(archive), RestoreRuleFilesOptions{})
+	if err != nil {
+		t.Fatalf("RestoreRuleFiles() error = %v", err)
+	}
+	want := []RuleFileResult{
+		{Name: "a.yml", Action: RuleFileActionCreated},
+		{Name: "b.yml", Action: RuleFileActionUnchanged},
+	}
+	if !reflect.DeepEqual(report.Files, want) {
+		t.Errorf("RestoreRuleFiles() files = %v, want %v", report.Files, want)
+	}
+	if content, _ := fake.ruleFile(source, "a.yml"); content != "groups:\n- name: a\n  rules: []\n" {
+		t.Errorf("restored content = %q", content)
+	}
+
+	// subset is restored onto a different deployment in dry-run mode
+	report, err = client.RestoreRuleFiles(context.Background(), target, bytes.NewReader(archive), RestoreRuleFilesOptions{Files: []string{"a.yml"}, DryRun: true})
+	if err != nil {
+		t.Fatalf("RestoreRuleFiles() error = %v", err)
+	}
+	want = []RuleFileResult{{Name: "a.yml", Action: RuleFileActionUpdated}}
+	if !report.DryRun || report.Manifest.DeploymentID != source || !reflect.DeepEqual(report.Files, want) {
+		t.Errorf("RestoreRuleFiles() dry run report = %+v", report)
+	}
+	if content, _ := fake.ruleFile(target, "a.yml"); content != "groups: []\n" {
+		t.Errorf("RestoreRuleFiles() changed rule file in dry-run mode: %q", content)
+	}
+
+	report, err = client.RestoreRuleFiles(context.Background(), target, bytes.NewReader(archive), RestoreRuleFilesOptions{Files: []string{"a.yml"}})
+	if err != nil {
+		t.Fatalf("RestoreRuleFiles() error = %v", err)
+	}
+	if content, _ := fake.ruleFile(target, "a.yml"); content != "groups:\n- name: a\n  rules: []\n" {
+		t.Errorf("restored content = %q", content)
+	}
+	if _, ok := fake.ruleFile(target, "other.yml"); !ok {
+		t.Errorf("RestoreRuleFiles() deleted rule file missing in the backup")
+	}
+
+	if _, err := client.RestoreRuleFiles(context.Background(), target, bytes.NewReader(archive), RestoreRuleFilesOptions{Files: []string{"missing.yml"}}); err == nil {
+		t.Errorf("RestoreRuleFiles() for missing file error = nil, want error")
+	}
+}
+
+func TestBackupRestoreRuleFiles_LegacyNames(t *testing.T) {
+	source := "123e4567-e89b-12d3-a456-426614174000"
+	target := "223e4567-e89b-12d3-a456-426614174001"
+	fake, client := newFakeCloud(t, WithRuleFilesValidation())
+	fake.addDeployment(DeploymentInfo{ID: source})
+	fake.addDeployment(DeploymentInfo{ID: target})
+	// the files had been uploaded before the name and content validation
+	fake.addRuleFile(source, "legacy rules?.yml", "groups: []\n")
+	fake.addRuleFile(source, ".hidden", "not: [a, rule, file]\n")
+
+	var buf bytes.Buffer
+	if _, err := client.BackupRuleFiles(context.Background(), source, &buf); err != nil {
+		t.Fatalf("BackupRuleFiles() error = %v", err)
+	}
+	report, err := client.RestoreRuleFiles(context.Background(), target, bytes.NewReader(buf.Bytes()), RestoreRuleFilesOptions{})
+	if err != nil {
+		t.Fatalf("RestoreRuleFiles() error = %v", err)
+	}
+	want := []RuleFileResult{
+		{Name: ".hidden", Action: RuleFileActionCreated},
+		{Name: "legacy rules?.yml", Action: RuleFileActionCreated},
+	}
+	if !reflect.DeepEqual(report.Files, want) {
+		t.Errorf("RestoreRuleFiles() files = %v, want %v", report.Files, want)
+	}
+	if content, _ := fake.ruleFile(target, ".hidden"); content != "not: [a, rule, file]\n" {
+		t.Errorf("restored content = %q", content)
+	}
+
+	// names which cannot be stored in the archive are refused before anything is written
+	fake.addRuleFile(source, "nested/rules.yml", "groups: []\n")
+	buf.Reset()
+	if _, err := client.BackupRuleFiles(context.Background(), source, &buf); err == nil || buf.Len() != 0 {
+		t.Errorf("BackupRuleFiles() with unsupported name error = %v, written %d bytes", err, buf.Len())
+	}
+}
+
+func TestReadRuleFilesBackup_Errors(t *testing.T) {
+	manifest := `{"version":1,"deployment_id":"x","files":[{"name":"a.yml","sha256":"%s","size":11}]}`
+	sum := sha256.Sum256([]byte("groups: []\n"))
+	validHash := hex.EncodeToString(sum[:])
+	tests := []struct {
+		name    string
+		entries map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing manifest",
+			entries: map[string]string{"rules/a.yml": "groups: []\n"},
+			wantErr: "doesn't contain manifest.json",
+		},
+		{
+			name: "hash mismatch",
+			entries: map[string]string{
+				"manifest.json": strings.Replace(manifest, "%s", validHash, 1),
+				"rules/a.yml":   "groups: {}\n",
+			},
+			wantErr: "doesn't match the hash",
+		},
+		{
+			name: "missing file",
+			entries: map[string]string{
+				"manifest.json": strings.Replace(manifest, "%s", validHash, 1),
+			},
+			wantErr: "lists 1 rule files, archive contains 0",
+		},
+		{
+			name: "unexpected entry",
+			entries: map[string]string{
+				"manifest.json":  strings.Replace(manifest, "%s", validHash, 1),
+				"rules/../x.yml": "groups: []\n",
+			},
+			wantErr: "unexpected entry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ReadRuleFilesBackup(bytes.NewReader(testTarGz(t, tt.entries)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadRuleFilesBackup() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, _, err := ReadRuleFilesBackup(strings.NewReader("not an archive")); err == nil {
+		t.Errorf("ReadRuleFilesBackup() for invalid archive error = nil, want error")
+	}
+}
+
+func testTarGz(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("WriteHeader() error = %v", err)
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
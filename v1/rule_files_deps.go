@@ -0,0 +1,436 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RuleNodeKind - kind of the rule in the rule dependency graph
+type RuleNodeKind string
+
+const (
+	// RuleNodeRecording - recording rule
+	RuleNodeRecording RuleNodeKind = "recording"
+	// RuleNodeAlerting - alerting rule
+	RuleNodeAlerting RuleNodeKind = "alerting"
+)
+
+// String returns string representation of the rule node kind
+func (k RuleNodeKind) String() string {
+	return string(k)
+}
+
+// RuleMetricExtractor returns names of metrics referenced by the rule expression.
+// exprcheck.MetricNames can be used for precise extraction with the MetricsQL parser.
+type RuleMetricExtractor func(expr string) ([]string, error)
+
+// RuleDependencyOption - option for AnalyzeRuleDependencies
+type RuleDependencyOption func(*ruleDependencyConfig)
+
+type ruleDependencyConfig struct {
+	extractMetrics RuleMetricExtractor
+}
+
+// WithRuleMetricExtractor replaces the built-in heuristic extraction of metric names from rule expressions
+func WithRuleMetricExtractor(fn RuleMetricExtractor) RuleDependencyOption {
+	return func(c *ruleDependencyConfig) {
+		c.extractMetrics = fn
+	}
+}
+
+// RuleNode - rule in the rule dependency graph
+type RuleNode struct {
+	// ID - unique identifier of the node in <file>/<group>/<rule> form, duplicates get #N suffix
+	ID string `json:"id"`
+	// Kind - kind of the rule
+	Kind RuleNodeKind `json:"kind"`
+	// File - name of the rule file
+	File string `json:"file"`
+	// Group - name of the group
+	Group string `json:"group"`
+	// Name - name of the alert or recorded metric
+	Name string `json:"name"`
+	// Metrics - sorted names of metrics referenced by the rule expression
+	Metrics []string `json:"metrics"`
+}
+
+// RuleDependencyEdge - recording rule output consumed by another rule
+type RuleDependencyEdge struct {
+	// From - ID of the recording rule
+	From string `json:"from"`
+	// To - ID of the rule consuming the recorded metric
+	To string `json:"to"`
+	// Metric - name of the recorded metric
+	Metric string `json:"metric"`
+}
+
+// UnrecordedSeriesReference - reference to a level:metric:operations series not produced by any recording rule
+type UnrecordedSeriesReference struct {
+	// Rule - ID of the rule referencing the series
+	Rule string `json:"rule"`
+	// Metric - name of the referenced series
+	Metric string `json:"metric"`
+}
+
+// RuleDependencyGraph - graph of recording rule outputs and rules consuming them
+type RuleDependencyGraph struct {
+	// Nodes - rules sorted by file name in the order of groups and rules
+	Nodes []RuleNode `json:"nodes"`
+	// Edges - dependencies between rules
+	Edges []RuleDependencyEdge `json:"edges"`
+	// Orphans - IDs of recording rules whose output isn't used by any rule.
+	// Such series may still be used outside of rules, e.g. in dashboards.
+	Orphans []string `json:"orphans"`
+	// Unrecorded - references to series named after the level:metric:operations convention
+	// that aren't produced by any recording rule
+	Unrecorded []UnrecordedSeriesReference `json:"unrecorded"`
+	// Cycles - groups of recording rules depending on each other, every cycle is sorted by rule ID
+	Cycles [][]string `json:"cycles"`
+}
+
+// AnalyzeRuleDependencies builds the dependency graph of alerting/recording rule files given by file name.
+// By default metric names are extracted from expressions heuristically, use WithRuleMetricExtractor for precise results.
+func AnalyzeRuleDependencies(files map[string]string, opts ...RuleDependencyOption) (*RuleDependencyGraph, error) {
+	cfg := ruleDependencyConfig{extractMetrics: extractRuleMetricNames}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := &RuleDependencyGraph{
+		Nodes:      []RuleNode{},
+		Edges:      []RuleDependencyEdge{},
+		Orphans:    []string{},
+		Unrecorded: []UnrecordedSeriesReference{},
+		Cycles:     [][]string{},
+	}
+	ids := make(map[string]int)
+	producers := make(map[string][]int)
+	for _, name := range names {
+		f, err := ParseRuleFile(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("rule file %q: %w", name, err)
+		}
+		for _, group := range f.Groups {
+			for _, rule := range group.Rules {
+				kind := RuleNodeAlerting
+				if _, ok := rule.(*RecordingRule); ok {
+					kind = RuleNodeRecording
+				}
+				metrics, err := cfg.extractMetrics(rule.RuleExpr())
+				if err != nil {
+					return nil, fmt.Errorf("rule file %q: group %q: rule %q: failed to extract metric names: %w", name, group.Name, rule.RuleName(), err)
+				}
+				metrics = uniqueSortedStrings(metrics)
+
+				id := name + "/" + group.Name + "/" + rule.RuleName()
+				ids[id]++
+				if n := ids[id]; n > 1 {
+					id += "#" + strconv.Itoa(n)
+				}
+				if kind == RuleNodeRecording {
+					producers[rule.RuleName()] = append(producers[rule.RuleName()], len(g.Nodes))
+				}
+				g.Nodes = append(g.Nodes, RuleNode{ID: id, Kind: kind, File: name, Group: group.Name, Name: rule.RuleName(), Metrics: metrics})
+			}
+		}
+	}
+
+	used := make([]bool, len(g.Nodes))
+	adjacency := make([][]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		for _, metric := range node.Metrics {
+			from, ok := producers[metric]
+			if !ok {
+				if strings.Contains(metric, ":") {
+					g.Unrecorded = append(g.Unrecorded, UnrecordedSeriesReference{Rule: node.ID, Metric: metric})
+				}
+				continue
+			}
+			for _, j := range from {
+				used[j] = true
+				adjacency[j] = append(adjacency[j], i)
+				g.Edges = append(g.Edges, RuleDependencyEdge{From: g.Nodes[j].ID, To: node.ID, Metric: metric})
+			}
+		}
+	}
+	for i, node := range g.Nodes {
+		if node.Kind == RuleNodeRecording && !used[i] {
+			g.Orphans = append(g.Orphans, node.ID)
+		}
+	}
+	for _, component := range findRuleCycles(adjacency) {
+		cycle := make([]string, 0, len(component))
+		for _, i := range component {
+			cycle = append(cycle, g.Nodes[i].ID)
+		}
+		sort.Strings(cycle)
+		g.Cycles = append(g.Cycles, cycle)
+	}
+	sort.Slice(g.Cycles, func(i, j int) bool {
+		return g.Cycles[i][0] < g.Cycles[j][0]
+	})
+	return g, nil
+}
+
+// findRuleCycles returns strongly connected components of the graph forming cycles
+// using Tarjan's algorithm: components with more than one node and nodes depending on themselves.
+func findRuleCycles(adjacency [][]int) [][]int {
+	var (
+		index    = make([]int, len(adjacency))
+		lowLink  = make([]int, len(adjacency))
+		onStack  = make([]bool, len(adjacency))
+		stack    []int
+		next     = 1
+		cycles   [][]int
+		strongly func(v int)
+	)
+	strongly = func(v int) {
+		index[v], lowLink[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		selfLoop := false
+		for _, w := range adjacency[v] {
+			switch {
+			case w == v:
+				selfLoop = true
+			case index[w] == 0:
+				strongly(w)
+				lowLink[v] = min(lowLink[v], lowLink[w])
+			case onStack[w]:
+				lowLink[v] = min(lowLink[v], index[w])
+			}
+		}
+		if lowLink[v] != index[v] {
+			return
+		}
+		var component []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			cycles = append(cycles, component)
+		}
+	}
+	for v := range adjacency {
+		if index[v] == 0 {
+			strongly(v)
+		}
+	}
+	return cycles
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Recording rules are drawn as boxes, alerting rules as ellipses.
+// Orphan recording rules are dashed, unrecorded series are drawn as red dashed nodes.
+func (g *RuleDependencyGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	orphans := make(map[string]bool, len(g.Orphans))
+	for _, id := range g.Orphans {
+		orphans[id] = true
+	}
+	_, _ = fmt.Fprintln(bw, "digraph rules {")
+	_, _ = fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, node := range g.Nodes {
+		attrs := "shape=ellipse"
+		if node.Kind == RuleNodeRecording {
+			attrs = "shape=box"
+		}
+		if orphans[node.ID] {
+			attrs += ", style=dashed"
+		}
+		_, _ = fmt.Fprintf(bw, "  %s [label=%s, tooltip=%s, %s];\n", strconv.Quote(node.ID), strconv.Quote(node.Name), strconv.Quote(node.ID), attrs)
+	}
+	for _, e := range g.Edges {
+		_, _ = fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	missing := make(map[string]bool)
+	for _, ref := range g.Unrecorded {
+		id := strconv.Quote("unrecorded/" + ref.Metric)
+		if !missing[ref.Metric] {
+			missing[ref.Metric] = true
+			_, _ = fmt.Fprintf(bw, "  %s [label=%s, shape=box, style=dashed, color=red];\n", id, strconv.Quote(ref.Metric))
+		}
+		_, _ = fmt.Fprintf(bw, "  %s -> %s [color=red];\n", id, strconv.Quote(ref.Rule))
+	}
+	_, _ = fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// AnalyzeDeploymentRuleDependencies builds the dependency graph of all alerting/recording rule files of the deployment.
+func (a *VMCloudAPIClient) AnalyzeDeploymentRuleDependencies(ctx context.Context, deploymentID string, opts ...RuleDependencyOption) (*RuleDependencyGraph, error) {
+	files, err := a.getDeploymentRuleFiles(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeRuleDependencies(files, opts...)
+}
+
+// ruleExprKeywords - identifiers of rule expressions that aren't metric names
+var ruleExprKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "if": true, "ifnot": true, "default": true,
+	"bool": true, "offset": true, "atan2": true, "inf": true, "nan": true,
+	"keep_metric_names": true, "limit": true, "group_left": true, "group_right": true,
+}
+
+// ruleExprGroupingKeywords - keywords followed by a parenthesized list of label names
+var ruleExprGroupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// extractRuleMetricNames extracts metric names from the rule expression without parsing it.
+// It skips strings, label matchers (except __name__ equality), range selectors, function names,
+// keywords and label lists of grouping modifiers.
+func extractRuleMetricNames(expr string) ([]string, error) {
+	var names []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipRuleExprString(expr, i)
+		case c == '#':
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case c == '{':
+			end := skipRuleExprUntil(expr, i, '}')
+			if name := ruleExprNameMatcher(expr[i+1 : max(end-1, i+1)]); name != "" {
+				names = append(names, name)
+			}
+			i = end
+		case c == '[':
+			i = skipRuleExprUntil(expr, i, ']')
+		case c >= '0' && c <= '9' || c == '.':
+			for i < len(expr) && (isRuleExprIdentChar(expr[i]) || expr[i] == '.') {
+				i++
+			}
+		case isRuleExprIdentChar(c):
+			start := i
+			for i < len(expr) && isRuleExprIdentChar(expr[i]) {
+				i++
+			}
+			ident := expr[start:i]
+			j := i
+			for j < len(expr) && (expr[j] == ' ' || expr[j] == '\t' || expr[j] == '\n' || expr[j] == '\r') {
+				j++
+			}
+			nextParen := j < len(expr) && expr[j] == '('
+			// aggregate functions may be followed by the grouping modifier, e.g. sum by (job) (...)
+			nextIdent := j
+			for nextIdent < len(expr) && isRuleExprIdentChar(expr[nextIdent]) {
+				nextIdent++
+			}
+			switch modifier := strings.ToLower(expr[j:nextIdent]); {
+			case modifier == "by" || modifier == "without":
+			case ruleExprGroupingKeywords[strings.ToLower(ident)] && nextParen:
+				i = skipRuleExprUntil(expr, j, ')')
+			case nextParen || ruleExprKeywords[strings.ToLower(ident)] || ruleExprGroupingKeywords[strings.ToLower(ident)]:
+			default:
+				names = append(names, ident)
+			}
+		default:
+			i++
+		}
+	}
+	return uniqueSortedStrings(names), nil
+}
+
+func isRuleExprIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':'
+}
+
+// skipRuleExprString returns the position after the quoted string starting at i
+func skipRuleExprString(expr string, i int) int {
+	quote := expr[i]
+	for i++; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\\' && quote != '`':
+			i++
+		case expr[i] == quote:
+			return i + 1
+		}
+	}
+	return len(expr)
+}
+
+// skipRuleExprUntil returns the position after the closing char matching the opening char at i, skipping strings
+func skipRuleExprUntil(expr string, i int, closing byte) int {
+	opening := expr[i]
+	depth := 0
+	for i < len(expr) {
+		switch c := expr[i]; {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipRuleExprString(expr, i)
+			continue
+		case c == opening:
+			depth++
+		case c == closing:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(expr)
+}
+
+// ruleExprNameMatcher returns the metric name from __name__="..." matcher of the label filters
+func ruleExprNameMatcher(filters string) string {
+	for i := 0; i < len(filters); {
+		switch c := filters[i]; {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipRuleExprString(filters, i)
+		case strings.HasPrefix(filters[i:], "__name__") && (i == 0 || !isRuleExprIdentChar(filters[i-1])):
+			rest := strings.TrimLeft(filters[i+len("__name__"):], " \t\n\r")
+			if !strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, "=~") {
+				return ""
+			}
+			rest = strings.TrimLeft(rest[1:], " \t\n\r")
+			if rest == "" || (rest[0] != '"' && rest[0] != '\'' && rest[0] != '`') {
+				return ""
+			}
+			end := skipRuleExprString(rest, 0)
+			value, err := strconv.Unquote(rest[:end])
+			if err != nil && rest[0] == '\'' {
+				value, err = strconv.Unquote(`"` + strings.ReplaceAll(rest[1:end-1], `"`, `\"`) + `"`)
+			}
+			if err != nil {
+				return ""
+			}
+			return value
+		default:
+			i++
+		}
+	}
+	return ""
+}
+
+func uniqueSortedStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
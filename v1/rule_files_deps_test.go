@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractRuleMetricNames(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{
+			expr: `sum by (job, instance) (rate(http_requests_total{job="api", path=~"/v1/.+"}[5m] offset 1h))`,
+			want: []string{"http_requests_total"},
+		},
+		{
+			expr: `job:http_errors:rate5m / on(job) group_left(team) job:http_requests:rate5m > bool 0.05`,
+			want: []string{"job:http_errors:rate5m", "job:http_requests:rate5m"},
+		},
+		{
+			expr: `{__name__="instance:cpu:ratio", mode!="idle"} or {__name__=~"other.*"} unless absent(up)`,
+			want: []string{"instance:cpu:ratio", "up"},
+		},
+		{
+			expr: "label_replace(node_load1, \"dst\", \"$1\", \"src\", \"(.*)\") * 1e3 # node_load5 in a comment",
+			want: []string{"node_load1"},
+		},
+		{
+			expr: `max_over_time(deriv(disk_used_bytes[1h:5m])[1d:]) @ end()`,
+			want: []string{"disk_used_bytes"},
+		},
+		{
+			expr: `vector(1)`,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		got, err := extractRuleMetricNames(tt.expr)
+		if err != nil {
+			t.Fatalf("extractRuleMetricNames(%q) error = %v", tt.expr, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractRuleMetricNames(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+var testDependencyRuleFiles = map[string]string{
+	"recording.yml": `groups:
+  - name: http
+    rules:
+      - record: job:http_requests:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m]))
+      - record: job:http_errors:rate5m
+        expr: sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))
+      - record: job:http_unused:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m]))
+  - name: loop
+    rules:
+      - record: a:loop:sum
+        expr: sum(b:loop:sum)
+      - record: b:loop:sum
+        expr: sum(a:loop:sum)
+`,
+	"alerts.yml": `groups:
+  - name: http
+    rules:
+      - alert: HighErrorRatio
+        expr: job:http_errors:rate5m / job:http_requests:rate5m > 0.05
+      - alert: HighLatency
+        expr: job:http_latency:p99 > 1
+`,
+}
+
+func TestAnalyzeRuleDependencies(t *testing.T) {
+	g, err := AnalyzeRuleDependencies(testDependencyRuleFiles)
+	if err != nil {
+		t.Fatalf("AnalyzeRuleDependencies() error = %v", err)
+	}
+	if len(g.Nodes) != 7 || g.Nodes[0].ID != "alerts.yml/http/HighErrorRatio" || g.Nodes[2].Kind != RuleNodeRecording {
+		t.Errorf("AnalyzeRuleDependencies() nodes = %+v", g.Nodes)
+	}
+	wantEdges := []RuleDependencyEdge{
+		{From: "recording.yml/http/job:http_errors:rate5m", To: "alerts.yml/http/HighErrorRatio", Metric: "job:http_errors:rate5m"},
+		{From: "recording.yml/http/job:http_requests:rate5m", To: "alerts.yml/http/HighErrorRatio", Metric: "job:http_requests:rate5m"},
+		{From: "recording.yml/loop/b:loop:sum", To: "recording.yml/loop/a:loop:sum", Metric: "b:loop:sum"},
+		{From: "recording.yml/loop/a:loop:sum", To: "recording.yml/loop/b:loop:sum", Metric: "a:loop:sum"},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("AnalyzeRuleDependencies() edges =\n%v\nwant\n%v", g.Edges, wantEdges)
+	}
+	if want := []string{"recording.yml/http/job:http_unused:rate5m"}; !reflect.DeepEqual(g.Orphans, want) {
+		t.Errorf("AnalyzeRuleDependencies() orphans = %v, want %v", g.Orphans, want)
+	}
+	if want := []UnrecordedSeriesReference{{Rule: "alerts.yml/http/HighLatency", Metric: "job:http_latency:p99"}}; !reflect.DeepEqual(g.Unrecorded, want) {
+		t.Errorf("AnalyzeRuleDependencies() unrecorded = %v, want %v", g.Unrecorded, want)
+	}
+	if want := [][]string{{"recording.yml/loop/a:loop:sum", "recording.yml/loop/b:loop:sum"}}; !reflect.DeepEqual(g.Cycles, want) {
+		t.Errorf("AnalyzeRuleDependencies() cycles = %v, want %v", g.Cycles, want)
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded RuleDependencyGraph
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(&decoded, g) {
+		t.Errorf("JSON round trip = %+v, error = %v", decoded, err)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	dot := buf.String()
+	for _, want := range []string{
+		"digraph rules {\n",
+		`"recording.yml/http/job:http_errors:rate5m" -> "alerts.yml/http/HighErrorRatio";`,
+		`"recording.yml/http/job:http_unused:rate5m" [label="job:http_unused:rate5m", tooltip="recording.yml/http/job:http_unused:rate5m", shape=box, style=dashed];`,
+		`"unrecorded/job:http_latency:p99" -> "alerts.yml/http/HighLatency" [color=red];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("WriteDOT() output doesn't contain %s:\n%s", want, dot)
+		}
+	}
+
+	extractErr := errors.New("parse error")
+	_, err = AnalyzeRuleDependencies(testDependencyRuleFiles, WithRuleMetricExtractor(func(string) ([]string, error) {
+		return nil, extractErr
+	}))
+	if !errors.Is(err, extractErr) {
+		t.Errorf("AnalyzeRuleDependencies() with failing extractor error = %v, want %v", err, extractErr)
+	}
+}
+
+func TestAnalyzeDeploymentRuleDependencies(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	for name, content := range testDependencyRuleFiles {
+		fake.addRuleFile(deploymentID, name, content)
+	}
+
+	g, err := client.AnalyzeDeploymentRuleDependencies(context.Background(), deploymentID)
+	if err != nil {
+		t.Fatalf("AnalyzeDeploymentRuleDependencies() error = %v", err)
+	}
+	if len(g.Nodes) != 7 || len(g.Edges) != 4 || len(g.Cycles) != 1 {
+		t.Errorf("AnalyzeDeploymentRuleDependencies() = %+v", g)
+	}
+}
@@ -0,0 +1,537 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// unifiedDiffContext - number of unchanged lines shown around changes in the unified diff
+const unifiedDiffContext = 3
+
+// RuleChangeKind - kind of the semantic change of a rule or a group
+type RuleChangeKind string
+
+const (
+	// RuleChangeAdded - rule or group has been added
+	RuleChangeAdded RuleChangeKind = "added"
+	// RuleChangeRemoved - rule or group has been removed
+	RuleChangeRemoved RuleChangeKind = "removed"
+	// RuleChangeChanged - rule or group settings have been changed
+	RuleChangeChanged RuleChangeKind = "changed"
+)
+
+func (k RuleChangeKind) String() string {
+	return string(k)
+}
+
+// RuleChange - semantic change of a single rule or group of the rule file
+type RuleChange struct {
+	// Group - name of the group
+	Group string `json:"group"`
+	// Rule - name of the alert or recorded metric, empty for changes of the group itself
+	Rule string `json:"rule,omitempty"`
+	// Kind - kind of the change
+	Kind RuleChangeKind `json:"kind"`
+	// Fields - names of changed fields, set only for RuleChangeChanged
+	Fields []string `json:"fields,omitempty"`
+}
+
+func (c RuleChange) String() string {
+	target := fmt.Sprintf("group %q", c.Group)
+	if c.Rule != "" {
+		target = fmt.Sprintf("rule %q in group %q", c.Rule, c.Group)
+	}
+	if len(c.Fields) > 0 {
+		return fmt.Sprintf("%s %s (%s)", target, c.Kind, strings.Join(c.Fields, ", "))
+	}
+	return fmt.Sprintf("%s %s", target, c.Kind)
+}
+
+// RuleFileDiff - difference between the current and the new content of the rule file
+type RuleFileDiff struct {
+	// Name - name of the rule file
+	Name string `json:"name"`
+	// Exists is false if the rule file doesn't exist yet and the new content is compared with an empty file
+	Exists bool `json:"exists"`
+	// Unified - textual difference in unified format, empty if the content is identical
+	Unified string `json:"unified"`
+	// Changes - semantic changes sorted by group and rule name.
+	// Formatting, comments and key ordering changes are not reported.
+	Changes []RuleChange `json:"changes"`
+}
+
+// Changed returns true if the rule file has semantic changes
+func (d RuleFileDiff) Changed() bool {
+	return len(d.Changes) > 0
+}
+
+// DiffDeploymentRuleFile compares the current content of the deployment rule file with newContent.
+// A missing rule file is compared as an empty one.
+func (a *VMCloudAPIClient) DiffDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName, newContent string) (RuleFileDiff, error) {
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return RuleFileDiff{}, err
+	}
+	if err := checkPathParam("rule file name", ruleFileName); err != nil {
+		return RuleFileDiff{}, err
+	}
+	exists, err := a.ruleFileExists(ctx, deploymentID, ruleFileName)
+	if err != nil {
+		return RuleFileDiff{}, err
+	}
+	var current string
+	if exists {
+		current, err = a.GetDeploymentRuleFileContent(ctx, deploymentID, ruleFileName)
+		if err != nil {
+			return RuleFileDiff{}, fmt.Errorf("failed to get rule file %q of deployment %q: %w", ruleFileName, deploymentID, err)
+		}
+	}
+	diff, err := DiffRuleFiles(ruleFileName, current, newContent)
+	diff.Exists = exists
+	return diff, err
+}
+
+// DiffRuleFiles compares two versions of the rule file content.
+// Both versions must be parseable with ParseRuleFile, the empty content is treated as a file without groups.
+func DiffRuleFiles(ruleFileName, oldContent, newContent string) (RuleFileDiff, error) {
+	diff := RuleFileDiff{
+		Name:    ruleFileName,
+		Exists:  true,
+		Unified: unifiedDiff("a/"+ruleFileName, "b/"+ruleFileName, oldContent, newContent),
+	}
+	oldFile, err := parseRuleFileForDiff(oldContent)
+	if err != nil {
+		return diff, fmt.Errorf("current content of rule file %q: %w", ruleFileName, err)
+	}
+	newFile, err := parseRuleFileForDiff(newContent)
+	if err != nil {
+		return diff, fmt.Errorf("new content of rule file %q: %w", ruleFileName, err)
+	}
+	diff.Changes, err = diffRuleGroups(oldFile.Groups, newFile.Groups)
+	return diff, err
+}
+
+func parseRuleFileForDiff(content string) (RuleFile, error) {
+	if strings.TrimSpace(content) == "" {
+		return RuleFile{}, nil
+	}
+	return ParseRuleFile(content)
+}
+
+// ruleDiffKey identifies the rule within the group.
+// Rules with the same name are matched by the order of their definition.
+type ruleDiffKey struct {
+	kind  string
+	name  string
+	index int
+}
+
+func diffRuleGroups(oldGroups, newGroups []RuleGroup) ([]RuleChange, error) {
+	changes := make([]RuleChange, 0)
+	oldByName := make(map[string]RuleGroup, len(oldGroups))
+	for _, g := range oldGroups {
+		oldByName[g.Name] = g
+	}
+	newByName := make(map[string]RuleGroup, len(newGroups))
+	for _, g := range newGroups {
+		newByName[g.Name] = g
+	}
+	for _, g := range oldGroups {
+		if _, ok := newByName[g.Name]; !ok {
+			changes = append(changes, RuleChange{Group: g.Name, Kind: RuleChangeRemoved})
+			for _, r := range g.Rules {
+				changes = append(changes, RuleChange{Group: g.Name, Rule: r.RuleName(), Kind: RuleChangeRemoved})
+			}
+		}
+	}
+	for _, newGroup := range newGroups {
+		oldGroup, ok := oldByName[newGroup.Name]
+		if !ok {
+			changes = append(changes, RuleChange{Group: newGroup.Name, Kind: RuleChangeAdded})
+			for _, r := range newGroup.Rules {
+				changes = append(changes, RuleChange{Group: newGroup.Name, Rule: r.RuleName(), Kind: RuleChangeAdded})
+			}
+			continue
+		}
+		groupChanges, err := diffRuleGroup(oldGroup, newGroup)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, groupChanges...)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Group != changes[j].Group {
+			return changes[i].Group < changes[j].Group
+		}
+		return changes[i].Rule < changes[j].Rule
+	})
+	return changes, nil
+}
+
+func diffRuleGroup(oldGroup, newGroup RuleGroup) ([]RuleChange, error) {
+	var changes []RuleChange
+	// compare group settings without rules
+	oldSettings, newSettings := oldGroup, newGroup
+	oldSettings.Rules, newSettings.Rules = nil, nil
+	fields, err := diffYAMLFields(oldSettings, newSettings)
+	if err != nil {
+		return nil, err
+	}
+	fields = slices.DeleteFunc(fields, func(f string) bool { return f == "rules" })
+	if len(fields) > 0 {
+		changes = append(changes, RuleChange{Group: newGroup.Name, Kind: RuleChangeChanged, Fields: fields})
+	}
+
+	oldKeys, oldRules := indexRulesForDiff(oldGroup.Rules)
+	newKeys, newRules := indexRulesForDiff(newGroup.Rules)
+	for _, key := range oldKeys {
+		if _, ok := newRules[key]; !ok {
+			changes = append(changes, RuleChange{Group: oldGroup.Name, Rule: key.name, Kind: RuleChangeRemoved})
+		}
+	}
+	for _, key := range newKeys {
+		oldRule, ok := oldRules[key]
+		if !ok {
+			changes = append(changes, RuleChange{Group: newGroup.Name, Rule: key.name, Kind: RuleChangeAdded})
+			continue
+		}
+		fields, err := diffYAMLFields(oldRule, newRules[key])
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			changes = append(changes, RuleChange{Group: newGroup.Name, Rule: key.name, Kind: RuleChangeChanged, Fields: fields})
+		}
+	}
+	return changes, nil
+}
+
+// indexRulesForDiff returns keys of rules in the order of definition and rules by key
+func indexRulesForDiff(rules []Rule) ([]ruleDiffKey, map[ruleDiffKey]Rule) {
+	keys := make([]ruleDiffKey, 0, len(rules))
+	result := make(map[ruleDiffKey]Rule, len(rules))
+	seen := make(map[ruleDiffKey]int)
+	for _, r := range rules {
+		key := ruleDiffKey{kind: "record", name: r.RuleName()}
+		if _, ok := r.(*AlertingRule); ok {
+			key.kind = "alert"
+		}
+		key.index = seen[key]
+		seen[key]++
+		keys = append(keys, key)
+		result[key] = r
+	}
+	return keys, result
+}
+
+// diffYAMLFields returns sorted names of top-level fields which differ between YAML representations of a and b.
+// Values are compared in canonical form, so key ordering, formatting and reflowed whitespace of expressions are ignored.
+func diffYAMLFields(a, b any) ([]string, error) {
+	aFields, err := canonicalYAMLFields(a)
+	if err != nil {
+		return nil, err
+	}
+	bFields, err := canonicalYAMLFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for name, value := range aFields {
+		if bValue, ok := bFields[name]; !ok || bValue != value {
+			fields = append(fields, name)
+		}
+	}
+	for name := range bFields {
+		if _, ok := aFields[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func canonicalYAMLFields(v any) (map[string]string, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, fmt.Errorf("cannot marshal rule: %w", err)
+	}
+	result := make(map[string]string, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return nil, fmt.Errorf("cannot decode field %s: %w", node.Content[i].Value, err)
+		}
+		if expr, ok := value.(string); ok && node.Content[i].Value == "expr" {
+			value = normalizeRuleExprWhitespace(expr)
+		}
+		// encoding/json sorts map keys, so the result doesn't depend on the key ordering
+		data, err := json.Marshal(trimYAMLStrings(value))
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode field %s: %w", node.Content[i].Value, err)
+		}
+		result[node.Content[i].Value] = string(data)
+	}
+	return result, nil
+}
+
+// trimYAMLStrings trims leading and trailing whitespace of all strings in the decoded YAML value,
+// so block and flow scalars with the same text are equal.
+func trimYAMLStrings(v any) any {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []any:
+		for i := range v {
+			v[i] = trimYAMLStrings(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = trimYAMLStrings(v[k])
+		}
+	}
+	return v
+}
+
+// normalizeRuleExprWhitespace replaces runs of whitespace outside string literals of the expression with a single space,
+// so expressions reflowed onto several lines are equal to the original ones.
+func normalizeRuleExprWhitespace(expr string) string {
+	var sb strings.Builder
+	space := false
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			space = false
+			end := skipRuleExprString(expr, i)
+			sb.WriteString(expr[i:end])
+			i = end
+		default:
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			space = false
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// diffOp - single line of the line-by-line difference: ' ' for unchanged, '-' for removed and '+' for added lines
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the difference between a and b in unified format
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	// aLine and bLine are 1-based numbers of the next lines in a and b
+	aLine, bLine := 1, 1
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			aLine++
+			bLine++
+			start++
+			continue
+		}
+		// extend the hunk while changes are separated by less than 2*context unchanged lines
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind == ' ' {
+				continue
+			}
+			if i-end > 2*unifiedDiffContext {
+				break
+			}
+			end = i + 1
+		}
+		from := max(start-unifiedDiffContext, 0)
+		to := min(end+unifiedDiffContext, len(ops))
+		hunkALine, hunkBLine := aLine-(start-from), bLine-(start-from)
+		var aCount, bCount int
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", unifiedRange(hunkALine, aCount), unifiedRange(hunkBLine, bCount))
+		for _, op := range ops[from:to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		for _, op := range ops[start:to] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+func unifiedRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line-1)
+	case 1:
+		return fmt.Sprintf("%d", line)
+	default:
+		return fmt.Sprintf("%d,%d", line, count)
+	}
+}
+
+// splitLines splits s into lines keeping line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script transforming a into b using the linear space variant
+// of the Myers algorithm: the middle of the edit path is found by searching from both ends,
+// and both halves are diffed recursively. Memory usage is O(N+M) unlike the classic variant storing every round.
+func diffLines(a, b []string) []diffOp {
+	return appendDiffOps(make([]diffOp, 0, len(a)+len(b)), a, b)
+}
+
+func appendDiffOps(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	x, y := -1, -1
+	if len(a) > 0 && len(b) > 0 {
+		x, y = diffMiddle(a, b)
+	}
+	if x < 0 {
+		for _, line := range a {
+			ops = append(ops, diffOp{kind: '-', line: line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{kind: '+', line: line})
+		}
+	} else {
+		ops = appendDiffOps(ops, a[:x], b[:y])
+		ops = appendDiffOps(ops, a[x:], b[y:])
+	}
+	for _, line := range common {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	return ops
+}
+
+// diffMiddle returns the point where the forward and reverse searches of the shortest edit path of a and b meet,
+// or -1, -1 if a and b have no common lines. a and b must be non-empty and must differ in the first and last lines,
+// so the returned point always splits the problem into smaller ones.
+func diffMiddle(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[offset+k] and reverse[offset+k] are the furthest x reached on diagonal k from the start and the end
+	forward := make([]int, 2*offset+1)
+	reverse := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], reverse[i] = -1, -1
+	}
+	forward[offset+1], reverse[offset+1] = 0, 0
+	delta := n - m
+	// with odd delta the paths can meet only during the forward search, otherwise during the reverse search
+	checkForward := delta%2 != 0
+	// diagonals leaving the edit graph are excluded from the following rounds
+	var fStart, fEnd, rStart, rEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case checkForward:
+				if j := offset + delta - k; j >= 0 && j < len(reverse) && reverse[j] != -1 && x >= n-reverse[j] {
+					return x, y
+				}
+			}
+		}
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && reverse[i-1] < reverse[i+1]) {
+				x = reverse[i+1]
+			} else {
+				x = reverse[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			reverse[i] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !checkForward:
+				if j := offset + delta - k; j >= 0 && j < len(forward) && forward[j] != -1 && forward[j] >= n-x {
+					fx := forward[j]
+					return fx, fx - (j - offset)
+				}
+			}
+		}
+	}
+	return -1, -1
+}
@@ -0,0 +1,274 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffRuleFiles(t *testing.T) {
+	oldContent := `groups:
+- name: a
+  interval: 1m
+  rules:
+  - alert: Down
+    expr: up == 0
+    for: 5m
+    labels:
+      severity: page
+      team: infra
+  - alert: HighLatency
+    expr: latency > 1
+  - record: job:up:sum
+    expr: sum(up) by (job)
+- name: removed
+  rules:
+  - alert: Old
+    expr: old > 0
+`
+	// key ordering, quoting and indentation changes must not be reported
+	newContent := `groups:
+  - name: a
+    interval: 2m
+    rules:
+      - alert: Down
+        for: 5m
+        expr: "up == 0"
+        labels:
+          team: infra
+          severity: page
+      - alert: HighLatency
+        expr: latency > 2
+        for: 10m
+      - alert: New
+        expr: new > 0
+  - name: added
+    rules:
+      - record: job:new:sum
+        expr: sum(new) by (job)
+`
+	diff, err := DiffRuleFiles("rules.yml", oldContent, newContent)
+	if err != nil {
+		t.Fatalf("DiffRuleFiles() error = %v", err)
+	}
+	want := []RuleChange{
+		{Group: "a", Kind: RuleChangeChanged, Fields: []string{"interval"}},
+		{Group: "a", Rule: "HighLatency", Kind: RuleChangeChanged, Fields: []string{"expr", "for"}},
+		{Group: "a", Rule: "New", Kind: RuleChangeAdded},
+		{Group: "a", Rule: "job:up:sum", Kind: RuleChangeRemoved},
+		{Group: "added", Kind: RuleChangeAdded},
+		{Group: "added", Rule: "job:new:sum", Kind: RuleChangeAdded},
+		{Group: "removed", Kind: RuleChangeRemoved},
+		{Group: "removed", Rule: "Old", Kind: RuleChangeRemoved},
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("DiffRuleFiles() changes =\n%v\nwant\n%v", diff.Changes, want)
+	}
+	if !diff.Changed() {
+		t.Errorf("Changed() = false, want true")
+	}
+	if !strings.HasPrefix(diff.Unified, "--- a/rules.yml\n+++ b/rules.yml\n@@ ") {
+		t.Errorf("DiffRuleFiles() unified diff has unexpected header:\n%s", diff.Unified)
+	}
+
+	f, err := ParseRuleFile(oldContent)
+	if err != nil {
+		t.Fatalf("ParseRuleFile() error = %v", err)
+	}
+	reformatted, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	diff, err = DiffRuleFiles("rules.yml", oldContent, reformatted)
+	if err != nil {
+		t.Fatalf("DiffRuleFiles() error = %v", err)
+	}
+	if diff.Changed() || diff.Unified == "" {
+		t.Errorf("DiffRuleFiles() for reformatted content: changes = %v, unified empty = %v", diff.Changes, diff.Unified == "")
+	}
+
+	if _, err := DiffRuleFiles("rules.yml", oldContent, "groups: [\n"); err == nil {
+		t.Errorf("DiffRuleFiles() for invalid content error = nil, want error")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "a\nb\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "single change with context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+		{
+			name: "missing newline at end",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("a", "b", tt.a, tt.b); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRuleFiles_ReflowedExpr(t *testing.T) {
+	oldContent := `groups:
+  - name: a
+    rules:
+      - alert: HighErrorRate
+        expr: sum(rate(http_errors_total{job="api", path="/a  b"}[5m])) by (job) / sum(rate(http_requests_total[5m])) by (job) > 0.1
+`
+	newContent := `groups:
+  - name: a
+    rules:
+      - alert: HighErrorRate
+        expr: |
+          sum(rate(http_errors_total{job="api", path="/a  b"}[5m])) by (job)
+            /
+          sum(rate(http_requests_total[5m])) by (job)
+            > 0.1
+`
+	diff, err := DiffRuleFiles("rules.yml", oldContent, newContent)
+	if err != nil {
+		t.Fatalf("DiffRuleFiles() error = %v", err)
+	}
+	if diff.Changed() {
+		t.Errorf("DiffRuleFiles() for reflowed expr changes = %v, want none", diff.Changes)
+	}
+
+	// whitespace inside string literals is significant
+	changedLiteral := strings.Replace(newContent, `path="/a  b"`, `path="/a b"`, 1)
+	diff, err = DiffRuleFiles("rules.yml", oldContent, changedLiteral)
+	if err != nil {
+		t.Fatalf("DiffRuleFiles() error = %v", err)
+	}
+	want := []RuleChange{{Group: "a", Rule: "HighErrorRate", Kind: RuleChangeChanged, Fields: []string{"expr"}}}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("DiffRuleFiles() changes = %v, want %v", diff.Changes, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	check := func(t *testing.T, a, b []string) {
+		t.Helper()
+		ops := diffLines(a, b)
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("diffLines(%q, %q) = %v doesn't transform a into b", a, b, ops)
+		}
+		// the shortest edit script keeps the longest common subsequence
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) has %d edits, want %d", a, b, edits, want)
+		}
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, rnd.IntN(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.IntN(4)))
+		}
+		return lines
+	}
+	for range 2000 {
+		check(t, randomLines(), randomLines())
+	}
+
+	// full rewrite of a large rule file with some lines kept
+	var a, b []string
+	for i := range 4000 {
+		a = append(a, fmt.Sprintf("old line %d", i))
+		if i%100 == 0 {
+			b = append(b, a[i])
+		}
+		b = append(b, fmt.Sprintf("new line %d", i))
+	}
+	check(t, a, b)
+}
+
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestDiffDeploymentRuleFile(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "rules.yml", "groups:\n- name: a\n  rules:\n  - alert: A\n    expr: up == 0\n")
+
+	diff, err := client.DiffDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", "groups:\n- name: a\n  rules:\n  - alert: A\n    expr: up == 1\n")
+	if err != nil {
+		t.Fatalf("DiffDeploymentRuleFile() error = %v", err)
+	}
+	want := []RuleChange{{Group: "a", Rule: "A", Kind: RuleChangeChanged, Fields: []string{"expr"}}}
+	if !diff.Exists || !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("DiffDeploymentRuleFile() = %+v, want changes %v", diff, want)
+	}
+
+	diff, err = client.DiffDeploymentRuleFile(context.Background(), deploymentID, "new.yml", "groups:\n- name: b\n  rules: []\n")
+	if err != nil {
+		t.Fatalf("DiffDeploymentRuleFile() error = %v", err)
+	}
+	want = []RuleChange{{Group: "b", Kind: RuleChangeAdded}}
+	if diff.Exists || !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("DiffDeploymentRuleFile() for missing file = %+v, want changes %v", diff, want)
+	}
+	if !strings.Contains(diff.Unified, "@@ -0,0 +1,3 @@") {
+		t.Errorf("DiffDeploymentRuleFile() unified diff for missing file:\n%s", diff.Unified)
+	}
+}
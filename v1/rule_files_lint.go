@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RuleLintCheck - identifier of the rule linter check
+type RuleLintCheck string
+
+const (
+	// RuleLintSeverityLabel - alerting rules must have the severity label with one of the allowed values
+	RuleLintSeverityLabel RuleLintCheck = "severity-label"
+	// RuleLintRequiredAnnotations - alerting rules must have all required annotations
+	RuleLintRequiredAnnotations RuleLintCheck = "required-annotations"
+	// RuleLintRecordingRuleName - recording rule names must follow the level:metric:operations form
+	RuleLintRecordingRuleName RuleLintCheck = "recording-rule-name"
+	// RuleLintPagingMinFor - paging alerts must have the for duration not less than the configured minimum
+	RuleLintPagingMinFor RuleLintCheck = "paging-min-for"
+	// RuleLintDuplicateAlertName - alert names must be unique across rule files.
+	// The same alert name can be used several times in one file, e.g. with warning and critical thresholds.
+	RuleLintDuplicateAlertName RuleLintCheck = "duplicate-alert-name"
+)
+
+// RuleLintChecks - all checks supported by the rule linter
+var RuleLintChecks = []RuleLintCheck{
+	RuleLintSeverityLabel,
+	RuleLintRequiredAnnotations,
+	RuleLintRecordingRuleName,
+	RuleLintPagingMinFor,
+	RuleLintDuplicateAlertName,
+}
+
+func (c RuleLintCheck) String() string {
+	return string(c)
+}
+
+var (
+	recordingRuleNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*:[a-zA-Z_][a-zA-Z0-9_]*:[a-zA-Z0-9_]+$`)
+	ruleDurationPartRegex  = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)(ms|s|m|h|d|w|y)`)
+
+	ruleDurationUnits = map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"y":  365 * 24 * time.Hour,
+	}
+)
+
+// RuleLintConfig - configuration of the rule linter.
+// Empty fields are replaced with values from DefaultRuleLintConfig.
+type RuleLintConfig struct {
+	// Disabled - checks that are not performed
+	Disabled []RuleLintCheck
+	// AllowedSeverities - allowed values of the severity label
+	AllowedSeverities []string
+	// RequiredAnnotations - annotations every alerting rule must have
+	RequiredAnnotations []string
+	// PagingSeverities - values of the severity label marking paging alerts
+	PagingSeverities []string
+	// MinPagingFor - minimum for duration of paging alerts
+	MinPagingFor time.Duration
+}
+
+// DefaultRuleLintConfig returns the default configuration of the rule linter with all checks enabled
+func DefaultRuleLintConfig() RuleLintConfig {
+	return RuleLintConfig{
+		AllowedSeverities:   []string{"critical", "warning", "info"},
+		RequiredAnnotations: []string{"summary", "runbook_url"},
+		PagingSeverities:    []string{"critical"},
+		MinPagingFor:        5 * time.Minute,
+	}
+}
+
+func (c RuleLintConfig) withDefaults() (RuleLintConfig, error) {
+	for _, check := range c.Disabled {
+		if !slices.Contains(RuleLintChecks, check) {
+			return c, fmt.Errorf("unknown rule lint check %q", check)
+		}
+	}
+	defaults := DefaultRuleLintConfig()
+	if len(c.AllowedSeverities) == 0 {
+		c.AllowedSeverities = defaults.AllowedSeverities
+	}
+	if len(c.RequiredAnnotations) == 0 {
+		c.RequiredAnnotations = defaults.RequiredAnnotations
+	}
+	if len(c.PagingSeverities) == 0 {
+		c.PagingSeverities = defaults.PagingSeverities
+	}
+	if c.MinPagingFor == 0 {
+		c.MinPagingFor = defaults.MinPagingFor
+	}
+	return c, nil
+}
+
+func (c RuleLintConfig) enabled(check RuleLintCheck) bool {
+	return !slices.Contains(c.Disabled, check)
+}
+
+// RuleLintIssue - violation of the team conventions found by the rule linter
+type RuleLintIssue struct {
+	// Check - identifier of the failed check
+	Check RuleLintCheck `json:"check"`
+	// File - name of the rule file
+	File string `json:"file"`
+	// Group - name of the group
+	Group string `json:"group"`
+	// Rule - name of the alert or recorded metric
+	Rule string `json:"rule"`
+	// Message - human-readable description of the issue
+	Message string `json:"message"`
+}
+
+func (i RuleLintIssue) String() string {
+	return fmt.Sprintf("%s: group %q: rule %q: %s [%s]", i.File, i.Group, i.Rule, i.Message, i.Check)
+}
+
+// LintRuleFiles checks the rule files given by name against team conventions.
+// Issues are sorted by file, group and rule in the order of definition.
+// Rule files must be parseable with ParseRuleFile, use ValidateRuleFile to check their structure.
+func LintRuleFiles(files map[string]string, cfg RuleLintConfig) ([]RuleLintIssue, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	issues := make([]RuleLintIssue, 0)
+	// alertLocations contains the file and group of the first definition of every alert name
+	type alertLocation struct {
+		file  string
+		group string
+	}
+	alertLocations := make(map[string]alertLocation)
+	for _, name := range names {
+		f, err := ParseRuleFile(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("rule file %q: %w", name, err)
+		}
+		for _, g := range f.Groups {
+			for _, rule := range g.Rules {
+				newIssue := func(check RuleLintCheck, format string, args ...any) {
+					issues = append(issues, RuleLintIssue{
+						Check:   check,
+						File:    name,
+						Group:   g.Name,
+						Rule:    rule.RuleName(),
+						Message: fmt.Sprintf(format, args...),
+					})
+				}
+				switch r := rule.(type) {
+				case *RecordingRule:
+					if cfg.enabled(RuleLintRecordingRuleName) && !recordingRuleNameRegex.MatchString(r.Record) {
+						newIssue(RuleLintRecordingRuleName, "recording rule name must have level:metric:operations form")
+					}
+				case *AlertingRule:
+					lintAlertingRule(cfg, g, r, newIssue)
+					if !cfg.enabled(RuleLintDuplicateAlertName) {
+						continue
+					}
+					first, ok := alertLocations[r.Alert]
+					if !ok {
+						alertLocations[r.Alert] = alertLocation{file: name, group: g.Name}
+					} else if first.file != name {
+						newIssue(RuleLintDuplicateAlertName, "alert name is already used in %s: group %q", first.file, first.group)
+					}
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+func lintAlertingRule(cfg RuleLintConfig, g RuleGroup, r *AlertingRule, newIssue func(check RuleLintCheck, format string, args ...any)) {
+	// group labels are added to all rules of the group and override rule labels
+	severity, ok := g.Labels.Get("severity")
+	if !ok {
+		severity, ok = r.Labels.Get("severity")
+	}
+	if cfg.enabled(RuleLintSeverityLabel) {
+		switch {
+		case !ok:
+			newIssue(RuleLintSeverityLabel, "missing severity label")
+		case !slices.Contains(cfg.AllowedSeverities, severity):
+			newIssue(RuleLintSeverityLabel, "severity %q is not one of %s", severity, strings.Join(cfg.AllowedSeverities, ", "))
+		}
+	}
+	if cfg.enabled(RuleLintRequiredAnnotations) {
+		for _, annotation := range cfg.RequiredAnnotations {
+			if v, ok := r.Annotations.Get(annotation); !ok || strings.TrimSpace(v) == "" {
+				newIssue(RuleLintRequiredAnnotations, "missing %s annotation", annotation)
+			}
+		}
+	}
+	if cfg.enabled(RuleLintPagingMinFor) && ok && slices.Contains(cfg.PagingSeverities, severity) {
+		var forDuration time.Duration
+		if r.For != "" {
+			d, err := parseRuleDuration(r.For)
+			if err != nil {
+				newIssue(RuleLintPagingMinFor, "cannot parse for duration: %s", err)
+				return
+			}
+			forDuration = d
+		}
+		if forDuration < cfg.MinPagingFor {
+			newIssue(RuleLintPagingMinFor, "paging alert must have for duration of at least %s, got %q", cfg.MinPagingFor, r.For)
+		}
+	}
+}
+
+// parseRuleDuration parses durations of rule files, which additionally support d, w and y units
+func parseRuleDuration(s string) (time.Duration, error) {
+	if !ruleDurationRegex.MatchString(s) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	for _, m := range ruleDurationPartRegex.FindAllStringSubmatch(s, -1) {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		d += time.Duration(v * float64(ruleDurationUnits[m[2]]))
+	}
+	return d, nil
+}
+
+// LintDeploymentRuleFiles checks all alerting/recording rule files of the deployment against team conventions.
+func (a *VMCloudAPIClient) LintDeploymentRuleFiles(ctx context.Context, deploymentID string, cfg RuleLintConfig) ([]RuleLintIssue, error) {
+	files, err := a.getDeploymentRuleFiles(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	return LintRuleFiles(files, cfg)
+}
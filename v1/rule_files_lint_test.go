@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLintRuleFiles(t *testing.T) {
+	files := map[string]string{
+		"a.yml": `groups:
+- name: infra
+  rules:
+  - alert: Good
+    expr: up == 0
+    for: 10m
+    labels:
+      severity: critical
+    annotations:
+      summary: Instance is down
+      runbook_url: https://example.com/runbook
+  - alert: NoSeverity
+    expr: up == 0
+    annotations:
+      summary: s
+      runbook_url: r
+  - alert: FastPage
+    expr: up == 0
+    for: 1m
+    labels:
+      severity: critical
+    annotations:
+      summary: s
+      runbook_url: " "
+  - record: job:up:sum
+    expr: sum(up) by (job)
+  - record: up_total
+    expr: sum(up)
+`,
+		"b.yml": `groups:
+- name: team
+  labels:
+    severity: urgent
+  rules:
+  - alert: Good
+    expr: up == 0
+    annotations:
+      summary: s
+      runbook_url: r
+`,
+	}
+	issues, err := LintRuleFiles(files, RuleLintConfig{})
+	if err != nil {
+		t.Fatalf("LintRuleFiles() error = %v", err)
+	}
+	want := []RuleLintIssue{
+		{Check: RuleLintSeverityLabel, File: "a.yml", Group: "infra", Rule: "NoSeverity", Message: "missing severity label"},
+		{Check: RuleLintRequiredAnnotations, File: "a.yml", Group: "infra", Rule: "FastPage", Message: "missing runbook_url annotation"},
+		{Check: RuleLintPagingMinFor, File: "a.yml", Group: "infra", Rule: "FastPage", Message: `paging alert must have for duration of at least 5m0s, got "1m"`},
+		{Check: RuleLintRecordingRuleName, File: "a.yml", Group: "infra", Rule: "up_total", Message: "recording rule name must have level:metric:operations form"},
+		{Check: RuleLintSeverityLabel, File: "b.yml", Group: "team", Rule: "Good", Message: "severity \"urgent\" is not one of critical, warning, info"},
+		{Check: RuleLintDuplicateAlertName, File: "b.yml", Group: "team", Rule: "Good", Message: `alert name is already used in a.yml: group "infra"`},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("LintRuleFiles() =\n%v\nwant\n%v", issues, want)
+	}
+
+	// disabled checks and custom settings
+	issues, err = LintRuleFiles(files, RuleLintConfig{
+		Disabled:            []RuleLintCheck{RuleLintDuplicateAlertName, RuleLintRecordingRuleName, RuleLintSeverityLabel},
+		RequiredAnnotations: []string{"summary"},
+		MinPagingFor:        time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("LintRuleFiles() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("LintRuleFiles() with disabled checks = %v, want no issues", issues)
+	}
+
+	if _, err := LintRuleFiles(files, RuleLintConfig{Disabled: []RuleLintCheck{"unknown"}}); err == nil {
+		t.Errorf("LintRuleFiles() with unknown check error = nil, want error")
+	}
+	if _, err := LintRuleFiles(map[string]string{"bad.yml": "groups: ["}, RuleLintConfig{}); err == nil {
+		t.Errorf("LintRuleFiles() with invalid file error = nil, want error")
+	}
+}
+
+func TestLintRuleFiles_DuplicateAlertName(t *testing.T) {
+	thresholds := `groups:
+- name: disk
+  rules:
+  - alert: DiskFull
+    expr: disk_used_ratio > 0.8
+    labels:
+      severity: warning
+    annotations:
+      summary: s
+      runbook_url: r
+  - alert: DiskFull
+    expr: disk_used_ratio > 0.95
+    for: 10m
+    labels:
+      severity: critical
+    annotations:
+      summary: s
+      runbook_url: r
+`
+	issues, err := LintRuleFiles(map[string]string{"a.yml": thresholds}, RuleLintConfig{})
+	if err != nil {
+		t.Fatalf("LintRuleFiles() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("LintRuleFiles() with the same alert name in one file = %v, want no issues", issues)
+	}
+
+	issues, err = LintRuleFiles(map[string]string{"a.yml": thresholds, "b.yml": thresholds}, RuleLintConfig{})
+	if err != nil {
+		t.Fatalf("LintRuleFiles() error = %v", err)
+	}
+	want := []RuleLintIssue{
+		{Check: RuleLintDuplicateAlertName, File: "b.yml", Group: "disk", Rule: "DiskFull", Message: `alert name is already used in a.yml: group "disk"`},
+		{Check: RuleLintDuplicateAlertName, File: "b.yml", Group: "disk", Rule: "DiskFull", Message: `alert name is already used in a.yml: group "disk"`},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("LintRuleFiles() with the same alert name in two files =\n%v\nwant\n%v", issues, want)
+	}
+}
+
+func TestRuleLintIssue_JSON(t *testing.T) {
+	data, err := json.Marshal(RuleLintIssue{Check: RuleLintSeverityLabel, File: "a.yml", Group: "g", Rule: "r", Message: "m"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"check":"severity-label","file":"a.yml","group":"g","rule":"r","message":"m"}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestParseRuleDuration(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{s: "5m", want: 5 * time.Minute},
+		{s: "1h30m", want: 90 * time.Minute},
+		{s: "1d12h", want: 36 * time.Hour},
+		{s: "1.5s", want: 1500 * time.Millisecond},
+		{s: "500ms", want: 500 * time.Millisecond},
+		{s: "5", wantErr: true},
+		{s: "5 minutes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseRuleDuration(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRuleDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRuleDuration() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintDeploymentRuleFiles(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "a.yml", "groups:\n- name: a\n  rules:\n  - record: bad\n    expr: up\n")
+
+	issues, err := client.LintDeploymentRuleFiles(context.Background(), deploymentID, RuleLintConfig{})
+	if err != nil {
+		t.Fatalf("LintDeploymentRuleFiles() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Check != RuleLintRecordingRuleName || issues[0].Rule != "bad" {
+		t.Errorf("LintDeploymentRuleFiles() = %v", issues)
+	}
+}
@@ -0,0 +1,231 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var invalidRuleFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// mimirEvalDelayFields - group fields of the Mimir/Cortex ruler converted to eval_delay
+var mimirEvalDelayFields = map[string]bool{
+	"query_offset":     true,
+	"evaluation_delay": true,
+}
+
+// MimirRuleNamespace - namespace of the Mimir/Cortex ruler with its rule groups
+type MimirRuleNamespace struct {
+	// Namespace - name of the namespace
+	Namespace string
+	// Groups - rule groups of the namespace
+	Groups []RuleGroup
+}
+
+// RuleFileName returns the name of the rule file for the namespace.
+// Characters not allowed in rule file names are replaced with underscores.
+func (n MimirRuleNamespace) RuleFileName() string {
+	return invalidRuleFileNameChars.ReplaceAllString(n.Namespace, "_") + ".yml"
+}
+
+// MimirConversionIssue - field of the Mimir/Cortex ruler rules that has been converted or removed
+type MimirConversionIssue struct {
+	// Namespace - name of the namespace
+	Namespace string `json:"namespace"`
+	// Group - name of the group
+	Group string `json:"group"`
+	// Rule - name of the alert or recorded metric, empty for group fields
+	Rule string `json:"rule,omitempty"`
+	// Field - name of the field
+	Field string `json:"field"`
+	// Message - description of the conversion
+	Message string `json:"message"`
+}
+
+func (i MimirConversionIssue) String() string {
+	if i.Rule != "" {
+		return fmt.Sprintf("namespace %q: group %q: rule %q: field %s: %s", i.Namespace, i.Group, i.Rule, i.Field, i.Message)
+	}
+	return fmt.Sprintf("namespace %q: group %q: field %s: %s", i.Namespace, i.Group, i.Field, i.Message)
+}
+
+// MimirImportReport - result of ImportMimirRules
+type MimirImportReport struct {
+	// Files - per-file results sorted by file name
+	Files []RuleFileResult `json:"files"`
+	// Issues - converted and removed fields
+	Issues []MimirConversionIssue `json:"issues"`
+}
+
+// ParseMimirRuleNamespaces parses rules exported from the Mimir/Cortex ruler. Two formats are supported:
+// the ruler API format mapping namespace names to lists of groups, and multi-document mimirtool/cortextool
+// rule files with namespace and groups fields. Namespaces are sorted by name.
+func ParseMimirRuleNamespaces(data []byte) ([]MimirRuleNamespace, error) {
+	byName := make(map[string][]RuleGroup)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document %d: %w", i, err)
+		}
+		if len(doc.Content) == 0 || isNullNode(doc.Content[0]) {
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("document %d: expected mapping of namespaces at line %d", i, root.Line)
+		}
+		if isMimirRuleFile(root) {
+			var f struct {
+				Namespace string      `yaml:"namespace"`
+				Groups    []RuleGroup `yaml:"groups"`
+			}
+			if err := root.Decode(&f); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			if f.Namespace == "" {
+				return nil, fmt.Errorf("document %d: namespace cannot be empty", i)
+			}
+			byName[f.Namespace] = append(byName[f.Namespace], f.Groups...)
+			continue
+		}
+		for j := 0; j+1 < len(root.Content); j += 2 {
+			namespace := root.Content[j].Value
+			var groups []RuleGroup
+			if err := root.Content[j+1].Decode(&groups); err != nil {
+				return nil, fmt.Errorf("document %d: namespace %q: %w", i, namespace, err)
+			}
+			byName[namespace] = append(byName[namespace], groups...)
+		}
+	}
+
+	namespaces := make([]MimirRuleNamespace, 0, len(byName))
+	for name, groups := range byName {
+		namespaces = append(namespaces, MimirRuleNamespace{Namespace: name, Groups: groups})
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Namespace < namespaces[j].Namespace
+	})
+	return namespaces, nil
+}
+
+// isMimirRuleFile returns true if the mapping has a scalar namespace field, as mimirtool rule files do
+func isMimirRuleFile(node *yaml.Node) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "namespace" && node.Content[i+1].Kind == yaml.ScalarNode {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertMimirRuleNamespaces converts namespaces of the Mimir/Cortex ruler to contents of rule files by file name.
+// Group fields query_offset and evaluation_delay are converted to eval_delay.
+// Other fields unsupported by VictoriaMetrics Cloud (e.g. source_tenants) are removed and reported as issues.
+func ConvertMimirRuleNamespaces(namespaces []MimirRuleNamespace) (map[string]string, []MimirConversionIssue, error) {
+	files := make(map[string]string, len(namespaces))
+	issues := make([]MimirConversionIssue, 0)
+	fileNamespaces := make(map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		name := ns.RuleFileName()
+		if previous, ok := fileNamespaces[name]; ok {
+			return nil, nil, fmt.Errorf("namespaces %q and %q map to the same rule file %q", previous, ns.Namespace, name)
+		}
+		fileNamespaces[name] = ns.Namespace
+
+		groups := make([]RuleGroup, 0, len(ns.Groups))
+		for _, g := range ns.Groups {
+			g, groupIssues := convertMimirRuleGroup(ns.Namespace, g)
+			issues = append(issues, groupIssues...)
+			groups = append(groups, g)
+		}
+		content, err := RuleFile{Groups: groups}.Marshal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("namespace %q: %w", ns.Namespace, err)
+		}
+		files[name] = content
+	}
+	return files, issues, nil
+}
+
+func convertMimirRuleGroup(namespace string, g RuleGroup) (RuleGroup, []MimirConversionIssue) {
+	var issues []MimirConversionIssue
+	newIssue := func(rule, field, message string) {
+		issues = append(issues, MimirConversionIssue{Namespace: namespace, Group: g.Name, Rule: rule, Field: field, Message: message})
+	}
+
+	var extra []*yaml.Node
+	for i := 0; i+1 < len(g.extra); i += 2 {
+		key, value := g.extra[i], g.extra[i+1]
+		switch {
+		case mimirEvalDelayFields[key.Value] && g.EvalDelay == "" && value.Kind == yaml.ScalarNode:
+			g.EvalDelay = value.Value
+			newIssue("", key.Value, "converted to eval_delay")
+		case ruleGroupFields[key.Value]:
+			extra = append(extra, key, value)
+		default:
+			newIssue("", key.Value, "not supported, removed")
+		}
+	}
+	g.extra = extra
+
+	rules := make([]Rule, 0, len(g.Rules))
+	for _, rule := range g.Rules {
+		var ruleExtra *[]*yaml.Node
+		switch r := rule.(type) {
+		case *AlertingRule:
+			copied := *r
+			rule, ruleExtra = &copied, &copied.extra
+		case *RecordingRule:
+			copied := *r
+			rule, ruleExtra = &copied, &copied.extra
+		}
+		if ruleExtra != nil {
+			var kept []*yaml.Node
+			for i := 0; i+1 < len(*ruleExtra); i += 2 {
+				key, value := (*ruleExtra)[i], (*ruleExtra)[i+1]
+				if ruleFields[key.Value] {
+					kept = append(kept, key, value)
+					continue
+				}
+				newIssue(rule.RuleName(), key.Value, "not supported, removed")
+			}
+			*ruleExtra = kept
+		}
+		rules = append(rules, rule)
+	}
+	g.Rules = rules
+	return g, issues
+}
+
+// ImportMimirRules converts rules exported from the Mimir/Cortex ruler with ParseMimirRuleNamespaces and
+// ConvertMimirRuleNamespaces and uploads every namespace as an alerting/recording rules file of the deployment.
+// Existing rule files with the same names are replaced.
+// On error, the returned report contains the files processed before the failure.
+func (a *VMCloudAPIClient) ImportMimirRules(ctx context.Context, deploymentID string, data []byte) (MimirImportReport, error) {
+	var report MimirImportReport
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return report, err
+	}
+	namespaces, err := ParseMimirRuleNamespaces(data)
+	if err != nil {
+		return report, err
+	}
+	files, issues, err := ConvertMimirRuleNamespaces(namespaces)
+	if err != nil {
+		return report, err
+	}
+	report.Issues = issues
+	report.Files, err = a.upsertRuleFiles(ctx, deploymentID, files)
+	return report, err
+}
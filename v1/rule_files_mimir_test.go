@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseMimirRuleNamespaces(t *testing.T) {
+	// ruler API format
+	apiFormat := `team/api:
+  - name: api
+    interval: 1m
+    query_offset: 1m
+    source_tenants: [tenant-a, tenant-b]
+    rules:
+      - alert: HighErrorRate
+        expr: rate(errors_total[5m]) > 1
+        labels:
+          severity: critical
+infra:
+  - name: nodes
+    align_evaluation_time_on_interval: true
+    rules:
+      - record: instance:cpu:rate5m
+        expr: rate(cpu_seconds_total[5m])
+        unknown_field: x
+`
+	// mimirtool rule files format
+	toolFormat := `namespace: infra
+groups:
+  - name: disks
+    evaluation_delay: 30s
+    rules:
+      - alert: DiskFull
+        expr: disk_free == 0
+---
+namespace: other
+groups: []
+`
+	namespaces, err := ParseMimirRuleNamespaces([]byte(apiFormat + "---\n" + toolFormat))
+	if err != nil {
+		t.Fatalf("ParseMimirRuleNamespaces() error = %v", err)
+	}
+	var names []string
+	for _, ns := range namespaces {
+		names = append(names, ns.Namespace)
+	}
+	if !reflect.DeepEqual(names, []string{"infra", "other", "team/api"}) {
+		t.Fatalf("ParseMimirRuleNamespaces() namespaces = %v", names)
+	}
+	if len(namespaces[0].Groups) != 2 || namespaces[0].Groups[1].Name != "disks" {
+		t.Errorf("ParseMimirRuleNamespaces() infra groups = %+v", namespaces[0].Groups)
+	}
+
+	files, issues, err := ConvertMimirRuleNamespaces(namespaces)
+	if err != nil {
+		t.Fatalf("ConvertMimirRuleNamespaces() error = %v", err)
+	}
+	wantIssues := []MimirConversionIssue{
+		{Namespace: "infra", Group: "nodes", Field: "align_evaluation_time_on_interval", Message: "not supported, removed"},
+		{Namespace: "infra", Group: "nodes", Rule: "instance:cpu:rate5m", Field: "unknown_field", Message: "not supported, removed"},
+		{Namespace: "infra", Group: "disks", Field: "evaluation_delay", Message: "converted to eval_delay"},
+		{Namespace: "team/api", Group: "api", Field: "query_offset", Message: "converted to eval_delay"},
+		{Namespace: "team/api", Group: "api", Field: "source_tenants", Message: "not supported, removed"},
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("ConvertMimirRuleNamespaces() issues =\n%v\nwant\n%v", issues, wantIssues)
+	}
+	want := `groups:
+  - name: api
+    interval: 1m
+    eval_delay: 1m
+    rules:
+      - alert: HighErrorRate
+        expr: rate(errors_total[5m]) > 1
+        labels:
+          severity: critical
+`
+	if files["team_api.yml"] != want {
+		t.Errorf("ConvertMimirRuleNamespaces() team_api.yml =\n%s\nwant\n%s", files["team_api.yml"], want)
+	}
+	for name, content := range files {
+		if err := ValidateRuleFile(name, content); err != nil {
+			t.Errorf("ValidateRuleFile(%s) error = %v", name, err)
+		}
+	}
+	// the source namespaces are not modified
+	if len(namespaces[0].Groups[0].Rules[0].(*RecordingRule).extra) != 2 {
+		t.Errorf("ConvertMimirRuleNamespaces() modified the source rule")
+	}
+
+	if _, _, err := ConvertMimirRuleNamespaces([]MimirRuleNamespace{{Namespace: "a/b"}, {Namespace: "a_b"}}); err == nil {
+		t.Errorf("ConvertMimirRuleNamespaces() with clashing names error = nil, want error")
+	}
+	if _, err := ParseMimirRuleNamespaces([]byte("- a\n")); err == nil {
+		t.Errorf("ParseMimirRuleNamespaces() with list error = nil, want error")
+	}
+}
+
+func TestImportMimirRules(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "infra.yml", "groups: []\n")
+
+	report, err := client.ImportMimirRules(context.Background(), deploymentID, []byte(`infra:
+  - name: a
+    source_tenants: [x]
+    rules: []
+apps:
+  - name: b
+    rules: []
+`))
+	if err != nil {
+		t.Fatalf("ImportMimirRules() error = %v", err)
+	}
+	wantFiles := []RuleFileResult{
+		{Name: "apps.yml", Action: RuleFileActionCreated},
+		{Name: "infra.yml", Action: RuleFileActionUpdated},
+	}
+	if !reflect.DeepEqual(report.Files, wantFiles) || len(report.Issues) != 1 {
+		t.Errorf("ImportMimirRules() = %+v", report)
+	}
+	if content, _ := fake.ruleFile(deploymentID, "infra.yml"); content != "groups:\n  - name: a\n    rules: []\n" {
+		t.Errorf("uploaded content = %q", content)
+	}
+}
@@ -0,0 +1,465 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// RuleLabel - single label or annotation of the rule
+type RuleLabel struct {
+	// Name - name of the label
+	Name string
+	// Value - value of the label
+	Value string
+}
+
+// RuleLabels - ordered list of labels or annotations.
+// It is stored as a list to preserve the order of keys when the rule file is written back.
+type RuleLabels []RuleLabel
+
+// Get returns the value of the label by name
+func (l RuleLabels) Get(name string) (string, bool) {
+	for _, label := range l {
+		if label.Name == name {
+			return label.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of the label by name. New labels are appended to the end.
+func (l *RuleLabels) Set(name, value string) {
+	for i := range *l {
+		if (*l)[i].Name == name {
+			(*l)[i].Value = value
+			return
+		}
+	}
+	*l = append(*l, RuleLabel{Name: name, Value: value})
+}
+
+// Delete removes the label by name
+func (l *RuleLabels) Delete(name string) {
+	for i := range *l {
+		if (*l)[i].Name == name {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+			return
+		}
+	}
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (l RuleLabels) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, label := range l {
+		appendYAMLField(node, label.Name, stringNode(label.Value))
+	}
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *RuleLabels) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: labels must be a mapping", value.Line)
+	}
+	*l = make(RuleLabels, 0, len(value.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		var v string
+		if err := value.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		*l = append(*l, RuleLabel{Name: value.Content[i].Value, Value: v})
+	}
+	return nil
+}
+
+// Rule is implemented by *AlertingRule and *RecordingRule
+type Rule interface {
+	// RuleName returns the name of the alert or the recorded metric
+	RuleName() string
+	// RuleExpr returns the expression of the rule
+	RuleExpr() string
+	isRule()
+}
+
+// AlertingRule - alerting rule of the group
+type AlertingRule struct {
+	// Alert - name of the alert
+	Alert string
+	// Expr - expression to evaluate
+	Expr string
+	// For - duration for which the condition must be true before firing (e.g. 5m)
+	For string
+	// KeepFiringFor - duration for which the alert keeps firing after the condition is resolved
+	KeepFiringFor string
+	// Labels - labels added to the alert
+	Labels RuleLabels
+	// Annotations - annotations added to the alert
+	Annotations RuleLabels
+
+	// extra - fields not covered by the struct, preserved as key/value nodes
+	extra []*yaml.Node
+}
+
+// RuleName returns the name of the alert
+func (r *AlertingRule) RuleName() string { return r.Alert }
+
+// RuleExpr returns the expression of the rule
+func (r *AlertingRule) RuleExpr() string { return r.Expr }
+
+func (r *AlertingRule) isRule() {}
+
+// MarshalYAML implements yaml.Marshaler
+func (r *AlertingRule) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	appendYAMLField(node, "alert", stringNode(r.Alert))
+	appendYAMLField(node, "expr", stringNode(r.Expr))
+	appendOptionalYAMLField(node, "for", r.For)
+	appendOptionalYAMLField(node, "keep_firing_for", r.KeepFiringFor)
+	if err := appendLabelsYAMLField(node, "labels", r.Labels); err != nil {
+		return nil, err
+	}
+	if err := appendLabelsYAMLField(node, "annotations", r.Annotations); err != nil {
+		return nil, err
+	}
+	node.Content = append(node.Content, r.extra...)
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (r *AlertingRule) UnmarshalYAML(value *yaml.Node) error {
+	*r = AlertingRule{}
+	return decodeYAMLFields(value, "alerting rule", map[string]any{
+		"alert":           &r.Alert,
+		"expr":            &r.Expr,
+		"for":             &r.For,
+		"keep_firing_for": &r.KeepFiringFor,
+		"labels":          &r.Labels,
+		"annotations":     &r.Annotations,
+	}, &r.extra)
+}
+
+// RecordingRule - recording rule of the group
+type RecordingRule struct {
+	// Record - name of the metric to record
+	Record string
+	// Expr - expression to evaluate
+	Expr string
+	// Labels - labels added to the recorded series
+	Labels RuleLabels
+
+	// extra - fields not covered by the struct, preserved as key/value nodes
+	extra []*yaml.Node
+}
+
+// RuleName returns the name of the recorded metric
+func (r *RecordingRule) RuleName() string { return r.Record }
+
+// RuleExpr returns the expression of the rule
+func (r *RecordingRule) RuleExpr() string { return r.Expr }
+
+func (r *RecordingRule) isRule() {}
+
+// MarshalYAML implements yaml.Marshaler
+func (r *RecordingRule) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	appendYAMLField(node, "record", stringNode(r.Record))
+	appendYAMLField(node, "expr", stringNode(r.Expr))
+	if err := appendLabelsYAMLField(node, "labels", r.Labels); err != nil {
+		return nil, err
+	}
+	node.Content = append(node.Content, r.extra...)
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (r *RecordingRule) UnmarshalYAML(value *yaml.Node) error {
+	*r = RecordingRule{}
+	return decodeYAMLFields(value, "recording rule", map[string]any{
+		"record": &r.Record,
+		"expr":   &r.Expr,
+		"labels": &r.Labels,
+	}, &r.extra)
+}
+
+// RuleGroup - group of alerting/recording rules evaluated together
+type RuleGroup struct {
+	// Name - name of the group, must be unique within the file
+	Name string
+	// Interval - evaluation interval of the group (optional)
+	Interval string
+	// EvalOffset - evaluation offset of the group (optional)
+	EvalOffset string
+	// EvalDelay - delay applied to the evaluation time (optional)
+	EvalDelay string
+	// Limit - limit of alerts or series produced by each rule (optional)
+	Limit int
+	// Concurrency - number of concurrently evaluated rules (optional)
+	Concurrency int
+	// Type - datasource type of the group (optional)
+	Type string
+	// Labels - labels added to all rules of the group
+	Labels RuleLabels
+	// Rules - rules of the group in the order of definition
+	Rules []Rule
+
+	// extra - fields not covered by the struct, preserved as key/value nodes
+	extra []*yaml.Node
+}
+
+// AlertingRule returns the alerting rule of the group by alert name or nil if it is not found
+func (g *RuleGroup) AlertingRule(name string) *AlertingRule {
+	for _, rule := range g.Rules {
+		if r, ok := rule.(*AlertingRule); ok && r.Alert == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// RecordingRule returns the recording rule of the group by recorded metric name or nil if it is not found
+func (g *RuleGroup) RecordingRule(name string) *RecordingRule {
+	for _, rule := range g.Rules {
+		if r, ok := rule.(*RecordingRule); ok && r.Record == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (g RuleGroup) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	appendYAMLField(node, "name", stringNode(g.Name))
+	appendOptionalYAMLField(node, "interval", g.Interval)
+	appendOptionalYAMLField(node, "eval_offset", g.EvalOffset)
+	appendOptionalYAMLField(node, "eval_delay", g.EvalDelay)
+	if g.Limit != 0 {
+		appendYAMLField(node, "limit", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(g.Limit)})
+	}
+	if g.Concurrency != 0 {
+		appendYAMLField(node, "concurrency", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(g.Concurrency)})
+	}
+	appendOptionalYAMLField(node, "type", g.Type)
+	if err := appendLabelsYAMLField(node, "labels", g.Labels); err != nil {
+		return nil, err
+	}
+	node.Content = append(node.Content, g.extra...)
+
+	rules := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, rule := range g.Rules {
+		var ruleNode yaml.Node
+		if err := ruleNode.Encode(rule); err != nil {
+			return nil, fmt.Errorf("cannot marshal rule %q of group %q: %w", rule.RuleName(), g.Name, err)
+		}
+		rules.Content = append(rules.Content, &ruleNode)
+	}
+	appendYAMLField(node, "rules", rules)
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (g *RuleGroup) UnmarshalYAML(value *yaml.Node) error {
+	*g = RuleGroup{}
+	var rules yaml.Node
+	err := decodeYAMLFields(value, "group", map[string]any{
+		"name":        &g.Name,
+		"interval":    &g.Interval,
+		"eval_offset": &g.EvalOffset,
+		"eval_delay":  &g.EvalDelay,
+		"limit":       &g.Limit,
+		"concurrency": &g.Concurrency,
+		"type":        &g.Type,
+		"labels":      &g.Labels,
+		"rules":       &rules,
+	}, &g.extra)
+	if err != nil {
+		return err
+	}
+	if rules.Kind == 0 || rules.Tag == "!!null" {
+		return nil
+	}
+	if rules.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: rules of group %q must be a list", rules.Line, g.Name)
+	}
+	for _, ruleNode := range rules.Content {
+		rule, err := decodeRule(ruleNode)
+		if err != nil {
+			return err
+		}
+		g.Rules = append(g.Rules, rule)
+	}
+	return nil
+}
+
+func decodeRule(node *yaml.Node) (Rule, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: rule must be a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "alert":
+			r := &AlertingRule{}
+			return r, node.Decode(r)
+		case "record":
+			r := &RecordingRule{}
+			return r, node.Decode(r)
+		}
+	}
+	return nil, fmt.Errorf("line %d: rule must have either alert or record field", node.Line)
+}
+
+// RuleFile - alerting/recording rules file.
+// Comments and formatting of the original file are not preserved, but the order of groups, rules,
+// labels and annotations is. Fields unknown to the model are preserved as is.
+type RuleFile struct {
+	// Groups - rule groups in the order of definition
+	Groups []RuleGroup
+
+	// extra - top-level fields not covered by the struct, preserved as key/value nodes
+	extra []*yaml.Node
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (f RuleFile) MarshalYAML() (any, error) {
+	groups := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, g := range f.Groups {
+		var groupNode yaml.Node
+		if err := groupNode.Encode(g); err != nil {
+			return nil, fmt.Errorf("cannot marshal group %q: %w", g.Name, err)
+		}
+		groups.Content = append(groups.Content, &groupNode)
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	appendYAMLField(node, "groups", groups)
+	node.Content = append(node.Content, f.extra...)
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (f *RuleFile) UnmarshalYAML(value *yaml.Node) error {
+	*f = RuleFile{}
+	return decodeYAMLFields(value, "rule file", map[string]any{
+		"groups": &f.Groups,
+	}, &f.extra)
+}
+
+// Group returns the group by name or nil if it is not found
+func (f *RuleFile) Group(name string) *RuleGroup {
+	for i := range f.Groups {
+		if f.Groups[i].Name == name {
+			return &f.Groups[i]
+		}
+	}
+	return nil
+}
+
+// ParseRuleFile parses the content of the alerting/recording rules file.
+// Use ValidateRuleFile for detailed validation with positioned errors.
+func ParseRuleFile(content string) (RuleFile, error) {
+	var f RuleFile
+	if err := yaml.Unmarshal([]byte(content), &f); err != nil {
+		return RuleFile{}, fmt.Errorf("failed to parse rule file: %w", err)
+	}
+	return f, nil
+}
+
+// Marshal returns the content of the rule file in YAML format
+func (f RuleFile) Marshal() (string, error) {
+	if f.Groups == nil {
+		f.Groups = []RuleGroup{}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return "", fmt.Errorf("failed to marshal rule file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal rule file: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GetDeploymentRuleFile retrieves and parses the alerting/recording rules file of a deployment by deployment ID and file name.
+func (a *VMCloudAPIClient) GetDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName string) (RuleFile, error) {
+	content, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, ruleFileName)
+	if err != nil {
+		return RuleFile{}, err
+	}
+	f, err := ParseRuleFile(content)
+	if err != nil {
+		return RuleFile{}, fmt.Errorf("rule file %q of deployment %q: %w", ruleFileName, deploymentID, err)
+	}
+	return f, nil
+}
+
+// PutDeploymentRuleFile marshals the rule file and uploads it as the alerting/recording rules file of a deployment
+// by deployment ID and file name. The rule file is created if it doesn't exist.
+func (a *VMCloudAPIClient) PutDeploymentRuleFile(ctx context.Context, deploymentID, ruleFileName string, ruleFile RuleFile) error {
+	content, err := ruleFile.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = a.UpsertDeploymentRuleFile(ctx, deploymentID, ruleFileName, content)
+	return err
+}
+
+// decodeYAMLFields decodes fields of the mapping node into the given destinations.
+// Fields without destination are appended to extra as key/value node pairs.
+func decodeYAMLFields(node *yaml.Node, what string, fields map[string]any, extra *[]*yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: %s must be a mapping", node.Line, what)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		dst, ok := fields[key.Value]
+		if !ok {
+			*extra = append(*extra, key, value)
+			continue
+		}
+		if n, ok := dst.(*yaml.Node); ok {
+			*n = *value
+			continue
+		}
+		if err := value.Decode(dst); err != nil {
+			return fmt.Errorf("cannot decode field %s of %s: %w", key.Value, what, err)
+		}
+	}
+	return nil
+}
+
+func stringNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if strings.Contains(value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+func appendYAMLField(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func appendOptionalYAMLField(node *yaml.Node, key, value string) {
+	if value != "" {
+		appendYAMLField(node, key, stringNode(value))
+	}
+}
+
+func appendLabelsYAMLField(node *yaml.Node, key string, labels RuleLabels) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	value, err := labels.MarshalYAML()
+	if err != nil {
+		return err
+	}
+	appendYAMLField(node, key, value.(*yaml.Node))
+	return nil
+}
@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"context"
+	"testing"
+)
+
+const testRuleFileContent = `groups:
+  - name: example
+    interval: 1m
+    limit: 10
+    labels:
+      team: infra
+      env: prod
+    params:
+      nocache: ["1"]
+    rules:
+      - alert: HighRequestLatency
+        expr: job:request_latency_seconds:mean5m{job="myjob"} > 0.5
+        for: 10m
+        labels:
+          severity: page
+        annotations:
+          summary: High request latency
+          description: |
+            Latency is {{ $value }}
+            on {{ $labels.instance }}
+        debug: true
+      - record: job:request_latency_seconds:mean5m
+        expr: avg by (job) (request_latency_seconds)
+        labels:
+          version: "1"
+`
+
+func TestRuleFile_RoundTrip(t *testing.T) {
+	f, err := ParseRuleFile(testRuleFileContent)
+	if err != nil {
+		t.Fatalf("ParseRuleFile() error = %v", err)
+	}
+	if len(f.Groups) != 1 || len(f.Groups[0].Rules) != 2 {
+		t.Fatalf("ParseRuleFile() = %+v, want 1 group with 2 rules", f)
+	}
+	group := f.Group("example")
+	if group == nil || group.Interval != "1m" || group.Limit != 10 {
+		t.Fatalf("Group() = %+v", group)
+	}
+	if group.Labels[0].Name != "team" || group.Labels[1].Name != "env" {
+		t.Errorf("group labels order is not preserved: %v", group.Labels)
+	}
+	alert := group.AlertingRule("HighRequestLatency")
+	if alert == nil || alert.For != "10m" {
+		t.Fatalf("AlertingRule() = %+v", alert)
+	}
+	if v, ok := alert.Labels.Get("severity"); !ok || v != "page" {
+		t.Errorf("Labels.Get(severity) = %q, %v", v, ok)
+	}
+	record := group.RecordingRule("job:request_latency_seconds:mean5m")
+	if record == nil || record.RuleExpr() != "avg by (job) (request_latency_seconds)" {
+		t.Fatalf("RecordingRule() = %+v", record)
+	}
+
+	got, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got != testRuleFileContent {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, testRuleFileContent)
+	}
+}
+
+func TestRuleFile_Modify(t *testing.T) {
+	f, err := ParseRuleFile(testRuleFileContent)
+	if err != nil {
+		t.Fatalf("ParseRuleFile() error = %v", err)
+	}
+	group := f.Group("example")
+	group.AlertingRule("HighRequestLatency").Expr = `job:request_latency_seconds:mean5m{job="myjob"} > 1`
+	group.Labels.Delete("env")
+	group.Labels.Set("team", "platform")
+	group.Rules = append(group.Rules, &AlertingRule{Alert: "Down", Expr: "up == 0", Labels: RuleLabels{{Name: "severity", Value: "true"}}})
+
+	content, err := f.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := ValidateRuleFile("rules.yml", content); err != nil {
+		t.Fatalf("ValidateRuleFile() error = %v\n%s", err, content)
+	}
+	f, err = ParseRuleFile(content)
+	if err != nil {
+		t.Fatalf("ParseRuleFile() error = %v", err)
+	}
+	group = f.Group("example")
+	if len(group.Labels) != 1 || group.Labels[0].Value != "platform" {
+		t.Errorf("group labels = %v, want [team=platform]", group.Labels)
+	}
+	if expr := group.AlertingRule("HighRequestLatency").Expr; expr != `job:request_latency_seconds:mean5m{job="myjob"} > 1` {
+		t.Errorf("modified expr = %q", expr)
+	}
+	down := group.AlertingRule("Down")
+	if down == nil {
+		t.Fatalf("added rule is missing:\n%s", content)
+	}
+	if v, _ := down.Labels.Get("severity"); v != "true" {
+		t.Errorf("string label value = %q, want %q", v, "true")
+	}
+}
+
+func TestParseRuleFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid yaml", content: "groups: [\n"},
+		{name: "rule without kind", content: "groups:\n- name: a\n  rules:\n  - expr: up\n"},
+		{name: "rules not a list", content: "groups:\n- name: a\n  rules: {}\n"},
+		{name: "invalid limit", content: "groups:\n- name: a\n  limit: many\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRuleFile(tt.content); err == nil {
+				t.Errorf("ParseRuleFile() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestGetPutDeploymentRuleFile(t *testing.T) {
+	fake, client := newFakeCloud(t)
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "dev"})
+	fake.addRuleFile(deploymentID, "rules.yml", testRuleFileContent)
+
+	f, err := client.GetDeploymentRuleFile(context.Background(), deploymentID, "rules.yml")
+	if err != nil {
+		t.Fatalf("GetDeploymentRuleFile() error = %v", err)
+	}
+	f.Groups = append(f.Groups, RuleGroup{
+		Name:  "new",
+		Rules: []Rule{&RecordingRule{Record: "job:up:sum", Expr: "sum(up) by (job)"}},
+	})
+	if err := client.PutDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", f); err != nil {
+		t.Fatalf("PutDeploymentRuleFile() error = %v", err)
+	}
+	content, _ := fake.ruleFile(deploymentID, "rules.yml")
+	want := testRuleFileContent + `  - name: new
+    rules:
+      - record: job:up:sum
+        expr: sum(up) by (job)
+`
+	if content != want {
+		t.Errorf("uploaded content =\n%s\nwant\n%s", content, want)
+	}
+
+	if _, err := client.GetDeploymentRuleFile(context.Background(), deploymentID, "missing.yml"); err == nil {
+		t.Errorf("GetDeploymentRuleFile() for missing file error = nil, want error")
+	}
+}
+
+func TestRuleFile_TopLevelFields(t *testing.T) {
+	content := `groups:
+  - name: example
+    rules:
+      - record: job:up:sum
+        expr: sum(up) by (job)
+x-owner:
+  team: infra
+revision: 3
+`
+	fake, client := newFakeCloud(t)
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "dev"})
+	fake.addRuleFile(deploymentID, "rules.yml", content)
+
+	f, err := client.GetDeploymentRuleFile(context.Background(), deploymentID, "rules.yml")
+	if err != nil {
+		t.Fatalf("GetDeploymentRuleFile() error = %v", err)
+	}
+	if err := client.PutDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", f); err != nil {
+		t.Fatalf("PutDeploymentRuleFile() error = %v", err)
+	}
+	if got, _ := fake.ruleFile(deploymentID, "rules.yml"); got != content {
+		t.Errorf("uploaded content =\n%s\nwant\n%s", got, content)
+	}
+}
@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// PrometheusRuleAPIVersion - API version of PrometheusRule custom resources of the Prometheus Operator
+	PrometheusRuleAPIVersion = "monitoring.coreos.com/v1"
+	// PrometheusRuleKind - kind of PrometheusRule custom resources of the Prometheus Operator
+	PrometheusRuleKind = "PrometheusRule"
+
+	defaultKubernetesNamespace = "default"
+)
+
+var invalidKubernetesNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// PrometheusRuleMetadata - Kubernetes object metadata of the PrometheusRule
+type PrometheusRuleMetadata struct {
+	// Name - name of the object
+	Name string `yaml:"name"`
+	// Namespace - namespace of the object, "default" is used if empty
+	Namespace string `yaml:"namespace,omitempty"`
+	// Labels - labels of the object
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Annotations - annotations of the object
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// PrometheusRule - PrometheusRule custom resource of the Prometheus Operator.
+// Its spec has the same format as the alerting/recording rules file.
+type PrometheusRule struct {
+	// APIVersion - API version of the resource
+	APIVersion string `yaml:"apiVersion"`
+	// Kind - kind of the resource
+	Kind string `yaml:"kind"`
+	// Metadata - object metadata
+	Metadata PrometheusRuleMetadata `yaml:"metadata"`
+	// Spec - rule groups of the resource
+	Spec RuleFile `yaml:"spec"`
+}
+
+// NewPrometheusRule returns the PrometheusRule with the given name and namespace containing groups of the rule file
+func NewPrometheusRule(name, namespace string, ruleFile RuleFile) PrometheusRule {
+	return PrometheusRule{
+		APIVersion: PrometheusRuleAPIVersion,
+		Kind:       PrometheusRuleKind,
+		Metadata: PrometheusRuleMetadata{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: ruleFile,
+	}
+}
+
+// RuleFileName returns the name of the rule file for the PrometheusRule in <namespace>_<name>.yml form
+func (r PrometheusRule) RuleFileName() string {
+	namespace := r.Metadata.Namespace
+	if namespace == "" {
+		namespace = defaultKubernetesNamespace
+	}
+	return namespace + "_" + r.Metadata.Name + ".yml"
+}
+
+// ParsePrometheusRules extracts PrometheusRule objects from multi-document Kubernetes YAML.
+// Objects of other kinds are skipped, items of List objects (e.g. kubectl get -o yaml output) are inspected as well.
+func ParsePrometheusRules(data []byte) ([]PrometheusRule, error) {
+	var result []PrometheusRule
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document %d: %w", i, err)
+		}
+		if len(doc.Content) == 0 || isNullNode(doc.Content[0]) {
+			continue
+		}
+		rules, err := decodePrometheusRules(doc.Content[0])
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		result = append(result, rules...)
+	}
+	return result, nil
+}
+
+func decodePrometheusRules(node *yaml.Node) ([]PrometheusRule, error) {
+	var header struct {
+		APIVersion string      `yaml:"apiVersion"`
+		Kind       string      `yaml:"kind"`
+		Items      []yaml.Node `yaml:"items"`
+	}
+	if err := node.Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to decode Kubernetes object: %w", err)
+	}
+	switch {
+	case strings.HasSuffix(header.Kind, "List"):
+		var result []PrometheusRule
+		for i := range header.Items {
+			rules, err := decodePrometheusRules(&header.Items[i])
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			result = append(result, rules...)
+		}
+		return result, nil
+	case header.Kind == PrometheusRuleKind && strings.HasPrefix(header.APIVersion, "monitoring.coreos.com/"):
+		var r PrometheusRule
+		if err := node.Decode(&r); err != nil {
+			return nil, fmt.Errorf("failed to decode PrometheusRule: %w", err)
+		}
+		if r.Metadata.Name == "" {
+			return nil, fmt.Errorf("invalid PrometheusRule at line %d: name cannot be empty", node.Line)
+		}
+		return []PrometheusRule{r}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// PrometheusRulesToRuleFiles converts PrometheusRule objects to contents of rule files by file name
+func PrometheusRulesToRuleFiles(rules []PrometheusRule) (map[string]string, error) {
+	files := make(map[string]string, len(rules))
+	for _, r := range rules {
+		name := r.RuleFileName()
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("duplicate PrometheusRule %s/%s", r.Metadata.Namespace, r.Metadata.Name)
+		}
+		content, err := r.Spec.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("invalid PrometheusRule %s/%s: %w", r.Metadata.Namespace, r.Metadata.Name, err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// MarshalPrometheusRules returns PrometheusRule objects as multi-document Kubernetes YAML
+func MarshalPrometheusRules(rules []PrometheusRule) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, r := range rules {
+		if r.Spec.Groups == nil {
+			r.Spec.Groups = []RuleGroup{}
+		}
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("failed to marshal PrometheusRule %s/%s: %w", r.Metadata.Namespace, r.Metadata.Name, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal PrometheusRules: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportPrometheusRules uploads PrometheusRule objects from multi-document Kubernetes YAML as alerting/recording
+// rule files of the deployment named by RuleFileName. Existing rule files with the same names are replaced.
+// On error, the returned results contain the files processed before the failure.
+func (a *VMCloudAPIClient) ImportPrometheusRules(ctx context.Context, deploymentID string, data []byte) ([]RuleFileResult, error) {
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return nil, err
+	}
+	rules, err := ParsePrometheusRules(data)
+	if err != nil {
+		return nil, err
+	}
+	files, err := PrometheusRulesToRuleFiles(rules)
+	if err != nil {
+		return nil, err
+	}
+	return a.upsertRuleFiles(ctx, deploymentID, files)
+}
+
+// ExportPrometheusRules returns all alerting/recording rule files of the deployment as PrometheusRule objects
+// in the given namespace. Objects are named after rule files without extension, converted to valid Kubernetes names.
+func (a *VMCloudAPIClient) ExportPrometheusRules(ctx context.Context, deploymentID, namespace string) ([]PrometheusRule, error) {
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return nil, err
+	}
+	names, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	sort.Strings(names)
+	rules := make([]PrometheusRule, 0, len(names))
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		f, err := a.GetDeploymentRuleFile(ctx, deploymentID, name)
+		if err != nil {
+			return nil, err
+		}
+		objectName := prometheusRuleName(name)
+		if previous, ok := seen[objectName]; ok {
+			return nil, fmt.Errorf("rule files %q and %q map to the same PrometheusRule name %q", previous, name, objectName)
+		}
+		seen[objectName] = name
+		rules = append(rules, NewPrometheusRule(objectName, namespace, f))
+	}
+	return rules, nil
+}
+
+// prometheusRuleName converts the rule file name to the valid Kubernetes object name
+func prometheusRuleName(ruleFileName string) string {
+	name := strings.TrimSuffix(ruleFileName, path.Ext(ruleFileName))
+	name = invalidKubernetesNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, ".-")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], ".-")
+	}
+	if name == "" {
+		name = "rules"
+	}
+	return name
+}
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPrometheusRules = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+data: {}
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: api-alerts
+  namespace: prod
+  labels:
+    release: prometheus
+spec:
+  groups:
+  - name: api
+    rules:
+    - alert: HighErrorRate
+      expr: rate(errors_total[5m]) > 1
+      for: 5m
+      labels:
+        severity: critical
+---
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: monitoring.coreos.com/v1
+  kind: PrometheusRule
+  metadata:
+    name: recording
+  spec:
+    groups:
+    - name: recording
+      rules:
+      - record: job:up:sum
+        expr: sum(up) by (job)
+`
+
+func TestParsePrometheusRules(t *testing.T) {
+	rules, err := ParsePrometheusRules([]byte(testPrometheusRules))
+	if err != nil {
+		t.Fatalf("ParsePrometheusRules() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("ParsePrometheusRules() returned %d rules, want 2", len(rules))
+	}
+	if rules[0].Metadata.Labels["release"] != "prometheus" || rules[0].RuleFileName() != "prod_api-alerts.yml" {
+		t.Errorf("ParsePrometheusRules() first rule = %+v", rules[0].Metadata)
+	}
+	if rules[1].RuleFileName() != "default_recording.yml" {
+		t.Errorf("RuleFileName() = %q, want default_recording.yml", rules[1].RuleFileName())
+	}
+
+	files, err := PrometheusRulesToRuleFiles(rules)
+	if err != nil {
+		t.Fatalf("PrometheusRulesToRuleFiles() error = %v", err)
+	}
+	want := `groups:
+  - name: api
+    rules:
+      - alert: HighErrorRate
+        expr: rate(errors_total[5m]) > 1
+        for: 5m
+        labels:
+          severity: critical
+`
+	if files["prod_api-alerts.yml"] != want {
+		t.Errorf("PrometheusRulesToRuleFiles() content =\n%s\nwant\n%s", files["prod_api-alerts.yml"], want)
+	}
+	if err := ValidateRuleFile("default_recording.yml", files["default_recording.yml"]); err != nil {
+		t.Errorf("ValidateRuleFile() error = %v", err)
+	}
+
+	if _, err := PrometheusRulesToRuleFiles(append(rules, rules[0])); err == nil {
+		t.Errorf("PrometheusRulesToRuleFiles() with duplicates error = nil, want error")
+	}
+	if _, err := ParsePrometheusRules([]byte("kind: PrometheusRule\napiVersion: monitoring.coreos.com/v1\nspec: {}\n")); err == nil {
+		t.Errorf("ParsePrometheusRules() without name error = nil, want error")
+	}
+	if _, err := ParsePrometheusRules([]byte("a: [")); err == nil {
+		t.Errorf("ParsePrometheusRules() with invalid YAML error = nil, want error")
+	}
+}
+
+func TestMarshalPrometheusRules(t *testing.T) {
+	f, err := ParseRuleFile("groups:\n- name: a\n  rules:\n  - record: job:up:sum\n    expr: sum(up) by (job)\n")
+	if err != nil {
+		t.Fatalf("ParseRuleFile() error = %v", err)
+	}
+	data, err := MarshalPrometheusRules([]PrometheusRule{
+		NewPrometheusRule("a", "monitoring", f),
+		NewPrometheusRule("empty", "", RuleFile{}),
+	})
+	if err != nil {
+		t.Fatalf("MarshalPrometheusRules() error = %v", err)
+	}
+	want := `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: a
+  namespace: monitoring
+spec:
+  groups:
+    - name: a
+      rules:
+        - record: job:up:sum
+          expr: sum(up) by (job)
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: empty
+spec:
+  groups: []
+`
+	if string(data) != want {
+		t.Errorf("MarshalPrometheusRules() =\n%s\nwant\n%s", data, want)
+	}
+
+	rules, err := ParsePrometheusRules(data)
+	if err != nil {
+		t.Fatalf("ParsePrometheusRules() error = %v", err)
+	}
+	if len(rules) != 2 || !reflect.DeepEqual(rules[0].Spec, f) {
+		t.Errorf("ParsePrometheusRules() after MarshalPrometheusRules() = %+v", rules)
+	}
+}
+
+func TestImportExportPrometheusRules(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "default_recording.yml", "groups: []\n")
+
+	results, err := client.ImportPrometheusRules(context.Background(), deploymentID, []byte(testPrometheusRules))
+	if err != nil {
+		t.Fatalf("ImportPrometheusRules() error = %v", err)
+	}
+	want := []RuleFileResult{
+		{Name: "default_recording.yml", Action: RuleFileActionUpdated},
+		{Name: "prod_api-alerts.yml", Action: RuleFileActionCreated},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("ImportPrometheusRules() = %v, want %v", results, want)
+	}
+
+	fake.addRuleFile(deploymentID, "Team_Rules.yaml", "groups: []\n")
+	rules, err := client.ExportPrometheusRules(context.Background(), deploymentID, "monitoring")
+	if err != nil {
+		t.Fatalf("ExportPrometheusRules() error = %v", err)
+	}
+	var names []string
+	for _, r := range rules {
+		if r.Metadata.Namespace != "monitoring" || r.Kind != PrometheusRuleKind {
+			t.Errorf("ExportPrometheusRules() object = %+v", r.Metadata)
+		}
+		names = append(names, r.Metadata.Name)
+	}
+	if got := strings.Join(names, ","); got != "team-rules,default-recording,prod-api-alerts" {
+		t.Errorf("ExportPrometheusRules() names = %s", got)
+	}
+	if len(rules[2].Spec.Groups) != 1 || rules[2].Spec.Groups[0].AlertingRule("HighErrorRate") == nil {
+		t.Errorf("ExportPrometheusRules() spec = %+v", rules[2].Spec)
+	}
+}
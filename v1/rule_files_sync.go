@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RuleFileAction - action performed (or planned in dry-run mode) with the rule file
+type RuleFileAction string
+
+const (
+	// RuleFileActionCreated - rule file has been created
+	RuleFileActionCreated RuleFileAction = "created"
+	// RuleFileActionUpdated - content of the rule file has been replaced
+	RuleFileActionUpdated RuleFileAction = "updated"
+	// RuleFileActionDeleted - rule file has been deleted
+	RuleFileActionDeleted RuleFileAction = "deleted"
+	// RuleFileActionUnchanged - rule file has been left as is
+	RuleFileActionUnchanged RuleFileAction = "unchanged"
+)
+
+func (a RuleFileAction) String() string {
+	return string(a)
+}
+
+// RuleFileResult - result of the operation with a single alerting/recording rules file
+type RuleFileResult struct {
+	// Name - name of the rule file
+	Name string `json:"name"`
+	// Action - action performed with the rule file
+	Action RuleFileAction `json:"action"`
+}
+
+// SyncRuleFilesOptions - options for SyncRuleFiles
+type SyncRuleFilesOptions struct {
+	// Prune enables deletion of remote rule files that are missing locally
+	Prune bool
+	// DryRun disables any changes, the report contains actions that would be performed
+	DryRun bool
+}
+
+// SyncRuleFilesReport - result of SyncRuleFiles
+type SyncRuleFilesReport struct {
+	// DryRun is true if no changes have been made
+	DryRun bool `json:"dry_run"`
+	// Files - per-file results sorted by file name
+	Files []RuleFileResult `json:"files"`
+}
+
+// Changed returns true if at least one rule file has been (or would be in dry-run mode) created, updated or deleted.
+func (r SyncRuleFilesReport) Changed() bool {
+	for _, f := range r.Files {
+		if f.Action != RuleFileActionUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncRuleFiles uploads *.yml and *.yaml files from the root of fsys as alerting/recording rule files of the deployment.
+// Only files with content different from the remote one are uploaded. Symlinks to regular files are followed.
+// Remote rule files missing in fsys are deleted only if opts.Prune is set.
+// On error, the returned report contains the files processed before the failure.
+func (a *VMCloudAPIClient) SyncRuleFiles(ctx context.Context, deploymentID string, fsys fs.FS, opts SyncRuleFilesOptions) (SyncRuleFilesReport, error) {
+	report := SyncRuleFilesReport{DryRun: opts.DryRun}
+	if err := checkDeploymentID(deploymentID); err != nil {
+		return report, err
+	}
+	local, err := readLocalRuleFiles(fsys)
+	if err != nil {
+		return report, err
+	}
+	for name := range local {
+		if err := ValidateRuleFileName(name); err != nil {
+			return report, err
+		}
+	}
+	remoteNames, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+	if err != nil {
+		return report, fmt.Errorf("failed to list rule files of deployment %q: %w", deploymentID, err)
+	}
+	remote := make(map[string]bool, len(remoteNames))
+	for _, name := range remoteNames {
+		remote[name] = true
+	}
+
+	names := make([]string, 0, len(local)+len(remote))
+	for name := range local {
+		names = append(names, name)
+	}
+	for name := range remote {
+		if _, ok := local[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, isLocal := local[name]
+		var action RuleFileAction
+		switch {
+		case !isLocal && !opts.Prune:
+			action = RuleFileActionUnchanged
+		case !isLocal:
+			action = RuleFileActionDeleted
+			if !opts.DryRun {
+				if err := a.DeleteDeploymentRuleFile(ctx, deploymentID, name); err != nil {
+					return report, err
+				}
+			}
+		case !remote[name]:
+			action = RuleFileActionCreated
+			if !opts.DryRun {
+				if err := a.putRuleFile(ctx, deploymentID, name, content, true); err != nil {
+					return report, err
+				}
+			}
+		default:
+			remoteContent, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, name)
+			if err != nil {
+				return report, fmt.Errorf("failed to get rule file %q of deployment %q: %w", name, deploymentID, err)
+			}
+			if sha256.Sum256([]byte(remoteContent)) == sha256.Sum256([]byte(content)) {
+				action = RuleFileActionUnchanged
+				break
+			}
+			action = RuleFileActionUpdated
+			if !opts.DryRun {
+				if err := a.putRuleFile(ctx, deploymentID, name, content, false); err != nil {
+					return report, err
+				}
+			}
+		}
+		report.Files = append(report.Files, RuleFileResult{Name: name, Action: action})
+	}
+	return report, nil
+}
+
+// readLocalRuleFiles reads *.yml and *.yaml files from the root directory of fsys
+func readLocalRuleFiles(fsys fs.FS) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule files directory: %w", err)
+	}
+	result := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ext := path.Ext(name); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			// symlinks are followed, so they aren't treated as missing files and pruned
+			info, err := fs.Stat(fsys, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rule file %q: %w", name, err)
+			}
+			if !info.Mode().IsRegular() {
+				continue
+			}
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file %q: %w", name, err)
+		}
+		result[name] = string(data)
+	}
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSyncRuleFiles(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	local := fstest.MapFS{
+		"changed.yml":   {Data: []byte("groups: [new]\n")},
+		"new.yaml":      {Data: []byte("groups: []\n")},
+		"same.yml":      {Data: []byte("groups: []\n")},
+		"README.md":     {Data: []byte("not a rule file")},
+		".hidden.yml":   {Data: []byte("groups: []\n")},
+		"nested/ok.yml": {Data: []byte("groups: []\n")},
+	}
+
+	tests := []struct {
+		name        string
+		opts        SyncRuleFilesOptions
+		want        []RuleFileResult
+		wantChanged bool
+		wantRemote  map[string]string
+	}{
+		{
+			name: "dry run with prune",
+			opts: SyncRuleFilesOptions{Prune: true, DryRun: true},
+			want: []RuleFileResult{
+				{Name: "changed.yml", Action: RuleFileActionUpdated},
+				{Name: "new.yaml", Action: RuleFileActionCreated},
+				{Name: "obsolete.yml", Action: RuleFileActionDeleted},
+				{Name: "same.yml", Action: RuleFileActionUnchanged},
+			},
+			wantChanged: true,
+			wantRemote: map[string]string{
+				"changed.yml":  "groups: [old]\n",
+				"obsolete.yml": "groups: []\n",
+				"same.yml":     "groups: []\n",
+			},
+		},
+		{
+			name: "sync without prune",
+			opts: SyncRuleFilesOptions{},
+			want: []RuleFileResult{
+				{Name: "changed.yml", Action: RuleFileActionUpdated},
+				{Name: "new.yaml", Action: RuleFileActionCreated},
+				{Name: "obsolete.yml", Action: RuleFileActionUnchanged},
+				{Name: "same.yml", Action: RuleFileActionUnchanged},
+			},
+			wantChanged: true,
+			wantRemote: map[string]string{
+				"changed.yml":  "groups: [new]\n",
+				"new.yaml":     "groups: []\n",
+				"obsolete.yml": "groups: []\n",
+				"same.yml":     "groups: []\n",
+			},
+		},
+		{
+			name: "sync with prune",
+			opts: SyncRuleFilesOptions{Prune: true},
+			want: []RuleFileResult{
+				{Name: "changed.yml", Action: RuleFileActionUpdated},
+				{Name: "new.yaml", Action: RuleFileActionCreated},
+				{Name: "obsolete.yml", Action: RuleFileActionDeleted},
+				{Name: "same.yml", Action: RuleFileActionUnchanged},
+			},
+			wantChanged: true,
+			wantRemote: map[string]string{
+				"changed.yml": "groups: [new]\n",
+				"new.yaml":    "groups: []\n",
+				"same.yml":    "groups: []\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeCloud(t)
+			fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+			fake.addRuleFile(deploymentID, "changed.yml", "groups: [old]\n")
+			fake.addRuleFile(deploymentID, "obsolete.yml", "groups: []\n")
+			fake.addRuleFile(deploymentID, "same.yml", "groups: []\n")
+
+			report, err := client.SyncRuleFiles(context.Background(), deploymentID, local, tt.opts)
+			if err != nil {
+				t.Fatalf("SyncRuleFiles() error = %v", err)
+			}
+			if len(report.Files) != len(tt.want) {
+				t.Fatalf("SyncRuleFiles() returned %d results, want %d: %+v", len(report.Files), len(tt.want), report.Files)
+			}
+			for i := range tt.want {
+				if report.Files[i] != tt.want[i] {
+					t.Errorf("SyncRuleFiles() result %d = %+v, want %+v", i, report.Files[i], tt.want[i])
+				}
+			}
+			if report.Changed() != tt.wantChanged {
+				t.Errorf("SyncRuleFiles() Changed() = %v, want %v", report.Changed(), tt.wantChanged)
+			}
+			if report.DryRun != tt.opts.DryRun {
+				t.Errorf("SyncRuleFiles() DryRun = %v, want %v", report.DryRun, tt.opts.DryRun)
+			}
+			for name, content := range tt.wantRemote {
+				got, ok := fake.ruleFile(deploymentID, name)
+				if !ok || got != content {
+					t.Errorf("remote rule file %q = %q (exists: %v), want %q", name, got, ok, content)
+				}
+			}
+			if tt.opts.DryRun && fake.requestCount(http.MethodPost, "/api/v1/deployments/"+deploymentID+"/rule-sets/files/new.yaml") != 0 {
+				t.Errorf("SyncRuleFiles() uploaded files in dry-run mode")
+			}
+			if fake.requestCount(http.MethodPost, "/api/v1/deployments/"+deploymentID+"/rule-sets/files/same.yml") != 0 {
+				t.Errorf("SyncRuleFiles() uploaded unchanged file")
+			}
+		})
+	}
+}
+
+func TestSyncRuleFiles_Symlinks(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	dir := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "shared.yml")
+	if err := os.WriteFile(shared, []byte("groups: []\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink(shared, filepath.Join(dir, "alerts.yml")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	// symlinks to directories are skipped
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "rules.yml")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+	fake.addRuleFile(deploymentID, "alerts.yml", "groups: []\n")
+
+	report, err := client.SyncRuleFiles(context.Background(), deploymentID, os.DirFS(dir), SyncRuleFilesOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("SyncRuleFiles() error = %v", err)
+	}
+	want := []RuleFileResult{{Name: "alerts.yml", Action: RuleFileActionUnchanged}}
+	if len(report.Files) != 1 || report.Files[0] != want[0] {
+		t.Errorf("SyncRuleFiles() = %+v, want %+v", report.Files, want)
+	}
+	if _, ok := fake.ruleFile(deploymentID, "alerts.yml"); !ok {
+		t.Errorf("SyncRuleFiles() deleted the remote rule file of the symlinked local file")
+	}
+
+	// dangling symlinks must not be treated as missing files
+	if err := os.Remove(shared); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if _, err := client.SyncRuleFiles(context.Background(), deploymentID, os.DirFS(dir), SyncRuleFilesOptions{Prune: true}); err == nil {
+		t.Errorf("SyncRuleFiles() with dangling symlink error = nil, want error")
+	}
+	if _, ok := fake.ruleFile(deploymentID, "alerts.yml"); !ok {
+		t.Errorf("SyncRuleFiles() deleted the remote rule file of the dangling symlink")
+	}
+}
+
+func TestSyncRuleFiles_UploadError(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "test"})
+	fake.failOn(http.MethodPost, "/api/v1/deployments/"+deploymentID+"/rule-sets/files/b.yml", http.StatusInternalServerError)
+
+	local := fstest.MapFS{
+		"a.yml": {Data: []byte("groups: []\n")},
+		"b.yml": {Data: []byte("groups: []\n")},
+	}
+	report, err := client.SyncRuleFiles(context.Background(), deploymentID, local, SyncRuleFilesOptions{})
+	if err == nil {
+		t.Fatalf("SyncRuleFiles() should return an error")
+	}
+	if len(report.Files) != 1 || report.Files[0].Name != "a.yml" {
+		t.Errorf("SyncRuleFiles() partial report = %+v, want only a.yml", report.Files)
+	}
+}
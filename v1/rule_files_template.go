@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	// DefaultRuleTemplateLeftDelim - default left delimiter of rule templates.
+	// It differs from {{ used by annotation templates, so they are left as is in the rendered rule file.
+	DefaultRuleTemplateLeftDelim = "[["
+	// DefaultRuleTemplateRightDelim - default right delimiter of rule templates
+	DefaultRuleTemplateRightDelim = "]]"
+)
+
+// RuleTemplate - template of the alerting/recording rules file rendered with per-deployment variables.
+// It is based on text/template with [[ and ]] delimiters, for example:
+//
+//	rules:
+//	  - alert: HighErrorRate
+//	    expr: rate(errors_total{[[ selector .Selector ]]}[5m]) > [[ .ErrorRateThreshold ]]
+//	    annotations:
+//	      summary: "High error rate on {{ $labels.instance }}"
+type RuleTemplate struct {
+	name              string
+	tmpl              *template.Template
+	validationOptions []RuleFileValidationOption
+}
+
+type ruleTemplateConfig struct {
+	leftDelim         string
+	rightDelim        string
+	funcs             template.FuncMap
+	validationOptions []RuleFileValidationOption
+}
+
+// RuleTemplateOption - option of NewRuleTemplate
+type RuleTemplateOption func(*ruleTemplateConfig)
+
+// WithRuleTemplateDelims sets custom delimiters of the rule template. They must differ from {{ and }}.
+func WithRuleTemplateDelims(left, right string) RuleTemplateOption {
+	return func(c *ruleTemplateConfig) {
+		c.leftDelim = left
+		c.rightDelim = right
+	}
+}
+
+// WithRuleTemplateFuncs adds functions available in the rule template. They override the built-in ones with the same name.
+func WithRuleTemplateFuncs(funcs template.FuncMap) RuleTemplateOption {
+	return func(c *ruleTemplateConfig) {
+		for name, f := range funcs {
+			c.funcs[name] = f
+		}
+	}
+}
+
+// WithRuleTemplateValidation sets options of ValidateRuleFile used to validate the rendered rule file
+func WithRuleTemplateValidation(opts ...RuleFileValidationOption) RuleTemplateOption {
+	return func(c *ruleTemplateConfig) {
+		c.validationOptions = opts
+	}
+}
+
+// ruleTemplateBuiltinFuncs returns functions available in rule templates by default
+func ruleTemplateBuiltinFuncs() template.FuncMap {
+	return template.FuncMap{
+		// join joins elements with the separator: [[ join "|" .Jobs ]]
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+		// quote returns the double-quoted string safe for YAML and MetricsQL: [[ quote .Env ]]
+		"quote": strconv.Quote,
+		// default returns the value if it is not empty, otherwise the default: [[ .For | default "5m" ]]
+		"default": func(def, value any) any {
+			if value == nil || value == "" {
+				return def
+			}
+			return value
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		// selector returns label matchers sorted by label name: [[ selector .Labels ]] -> env="prod",job="api"
+		"selector": func(labels map[string]string) string {
+			names := make([]string, 0, len(labels))
+			for name := range labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			matchers := make([]string, 0, len(names))
+			for _, name := range names {
+				matchers = append(matchers, name+"="+strconv.Quote(labels[name]))
+			}
+			return strings.Join(matchers, ",")
+		},
+	}
+}
+
+// NewRuleTemplate parses the rule file template. The name is used in errors and validation issues.
+func NewRuleTemplate(name, text string, opts ...RuleTemplateOption) (*RuleTemplate, error) {
+	cfg := &ruleTemplateConfig{
+		leftDelim:  DefaultRuleTemplateLeftDelim,
+		rightDelim: DefaultRuleTemplateRightDelim,
+		funcs:      ruleTemplateBuiltinFuncs(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.leftDelim == "" || cfg.rightDelim == "" {
+		return nil, fmt.Errorf("rule template delimiters cannot be empty")
+	}
+	if cfg.leftDelim == "{{" || cfg.rightDelim == "}}" {
+		return nil, fmt.Errorf("rule template delimiters cannot be {{ or }}, they are used by annotation templates")
+	}
+	tmpl, err := template.New(name).
+		Delims(cfg.leftDelim, cfg.rightDelim).
+		Funcs(cfg.funcs).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rule template %q: %w", name, err)
+	}
+	return &RuleTemplate{
+		name:              name,
+		tmpl:              tmpl,
+		validationOptions: cfg.validationOptions,
+	}, nil
+}
+
+// Render renders the rule file with the given variables and validates the result with ValidateRuleFile
+func (t *RuleTemplate) Render(vars any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render rule template %q: %w", t.name, err)
+	}
+	content := buf.String()
+	if err := ValidateRuleFile(t.name, content, t.validationOptions...); err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+// CreateDeploymentRuleFileFromTemplate renders the rule template with the given variables and creates
+// a new alerting/recording rules file for a deployment by deployment ID and file name.
+func (a *VMCloudAPIClient) CreateDeploymentRuleFileFromTemplate(ctx context.Context, deploymentID, ruleFileName string, tmpl *RuleTemplate, vars any) error {
+	content, err := tmpl.Render(vars)
+	if err != nil {
+		return err
+	}
+	return a.CreateDeploymentRuleFileContent(ctx, deploymentID, ruleFileName, content)
+}
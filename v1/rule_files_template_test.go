@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testRuleTemplate = `groups:
+- name: [[ .Env ]]-api
+  rules:
+  - alert: HighErrorRate
+    expr: sum(rate(errors_total{[[ selector .Selector ]]}[5m])) > [[ .Threshold ]]
+    for: [[ .For | default "5m" ]]
+    labels:
+      env: [[ quote .Env ]]
+      jobs: [[ join "|" .Jobs ]]
+    annotations:
+      summary: "High error rate on {{ $labels.instance }}"
+`
+
+type testRuleTemplateVars struct {
+	Env       string
+	Selector  map[string]string
+	Threshold float64
+	For       string
+	Jobs      []string
+}
+
+func TestRuleTemplate_Render(t *testing.T) {
+	tmpl, err := NewRuleTemplate("api.yml", testRuleTemplate)
+	if err != nil {
+		t.Fatalf("NewRuleTemplate() error = %v", err)
+	}
+	got, err := tmpl.Render(testRuleTemplateVars{
+		Env:       "prod",
+		Selector:  map[string]string{"job": "api", "env": "prod"},
+		Threshold: 0.5,
+		Jobs:      []string{"api", "web"},
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `groups:
+- name: prod-api
+  rules:
+  - alert: HighErrorRate
+    expr: sum(rate(errors_total{env="prod",job="api"}[5m])) > 0.5
+    for: 5m
+    labels:
+      env: "prod"
+      jobs: api|web
+    annotations:
+      summary: "High error rate on {{ $labels.instance }}"
+`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	// missing variables and invalid results are errors
+	if _, err := tmpl.Render(map[string]any{"Env": "prod"}); err == nil {
+		t.Errorf("Render() with missing variables error = nil, want error")
+	}
+	invalid, err := NewRuleTemplate("invalid.yml", "groups:\n- name: a\n  interval: [[ .Interval ]]\n")
+	if err != nil {
+		t.Fatalf("NewRuleTemplate() error = %v", err)
+	}
+	var validationErr *RuleFileValidationError
+	if _, err := invalid.Render(map[string]string{"Interval": "soon"}); !errors.As(err, &validationErr) {
+		t.Errorf("Render() error = %v, want RuleFileValidationError", err)
+	}
+}
+
+func TestNewRuleTemplate_Options(t *testing.T) {
+	tmpl, err := NewRuleTemplate("rules.yml", "groups:\n- name: <% shout .Name %>\n  rules: []\n",
+		WithRuleTemplateDelims("<%", "%>"),
+		WithRuleTemplateFuncs(map[string]any{"shout": func(s string) string { return strings.ToUpper(s) + "!" }}),
+	)
+	if err != nil {
+		t.Fatalf("NewRuleTemplate() error = %v", err)
+	}
+	got, err := tmpl.Render(map[string]string{"Name": "a"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "groups:\n- name: A!\n  rules: []\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := NewRuleTemplate("rules.yml", "groups: []", WithRuleTemplateDelims("{{", "}}")); err == nil {
+		t.Errorf("NewRuleTemplate() with {{ }} delimiters error = nil, want error")
+	}
+	if _, err := NewRuleTemplate("rules.yml", "groups: [[ .A "); err == nil {
+		t.Errorf("NewRuleTemplate() with invalid template error = nil, want error")
+	}
+
+	tmpl, err = NewRuleTemplate("rules.yml", "groups:\n- name: a\n  rules:\n  - record: [[ .Name ]]\n    expr: up\n",
+		WithRuleTemplateValidation(WithRecordingRuleNameCheck(func(name string) error {
+			if !strings.Contains(name, ":") {
+				return errors.New("must contain colons")
+			}
+			return nil
+		})),
+	)
+	if err != nil {
+		t.Fatalf("NewRuleTemplate() error = %v", err)
+	}
+	if _, err := tmpl.Render(map[string]string{"Name": "up_sum"}); err == nil {
+		t.Errorf("Render() with validation options error = nil, want error")
+	}
+}
+
+func TestCreateDeploymentRuleFileFromTemplate(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+
+	tmpl, err := NewRuleTemplate("api.yml", testRuleTemplate)
+	if err != nil {
+		t.Fatalf("NewRuleTemplate() error = %v", err)
+	}
+	vars := testRuleTemplateVars{Env: "dev", Threshold: 1, For: "1m"}
+	if err := client.CreateDeploymentRuleFileFromTemplate(context.Background(), deploymentID, "api.yml", tmpl, vars); err != nil {
+		t.Fatalf("CreateDeploymentRuleFileFromTemplate() error = %v", err)
+	}
+	content, ok := fake.ruleFile(deploymentID, "api.yml")
+	if !ok || !strings.Contains(content, "expr: sum(rate(errors_total{}[5m])) > 1\n    for: 1m\n") {
+		t.Errorf("uploaded content =\n%s", content)
+	}
+	var existsErr *RuleFileAlreadyExistsError
+	if err := client.CreateDeploymentRuleFileFromTemplate(context.Background(), deploymentID, "api.yml", tmpl, vars); !errors.As(err, &existsErr) {
+		t.Errorf("CreateDeploymentRuleFileFromTemplate() error = %v, want RuleFileAlreadyExistsError", err)
+	}
+}
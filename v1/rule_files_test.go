@@ -3,7 +3,10 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -95,15 +98,32 @@ groups:
 	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
 	ruleFileName := "alert1.yml"
 
-	// Setup test server
-	server, client := setupTestServer(t, http.StatusOK, "", "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
-	defer server.Close()
+	// Setup fake server with the existing rule file
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, ruleFileName, "groups: []\n")
 
 	// Call the method
 	err := client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, ruleFileName, ruleContent)
 	if err != nil {
 		t.Fatalf("UpdateDeploymentRuleFileContent() error = %v", err)
 	}
+	if content, _ := fake.ruleFile(deploymentID, ruleFileName); content != ruleContent {
+		t.Errorf("UpdateDeploymentRuleFileContent() stored content = %s, want %s", content, ruleContent)
+	}
+
+	// Updating a missing rule file must fail
+	err = client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, "missing.yml", ruleContent)
+	var notFoundErr *RuleFileNotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Fatalf("UpdateDeploymentRuleFileContent() error = %v, want RuleFileNotFoundError", err)
+	}
+	if notFoundErr.Name != "missing.yml" || notFoundErr.DeploymentID != deploymentID {
+		t.Errorf("UpdateDeploymentRuleFileContent() error = %+v", notFoundErr)
+	}
+	if _, ok := fake.ruleFile(deploymentID, "missing.yml"); ok {
+		t.Errorf("UpdateDeploymentRuleFileContent() created the missing rule file")
+	}
 }
 
 func TestCreateDeploymentRuleFileContent(t *testing.T) {
@@ -123,15 +143,193 @@ groups:
 	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
 	ruleFileName := "new-alert.yml"
 
-	// Setup test server
-	server, client := setupTestServer(t, http.StatusOK, "", "/api/v1/deployments", deploymentID, "rule-sets", "files", ruleFileName)
-	defer server.Close()
+	// Setup fake server
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
 
 	// Call the method
 	err := client.CreateDeploymentRuleFileContent(context.Background(), deploymentID, ruleFileName, ruleContent)
 	if err != nil {
 		t.Fatalf("CreateDeploymentRuleFileContent() error = %v", err)
 	}
+	if content, _ := fake.ruleFile(deploymentID, ruleFileName); content != ruleContent {
+		t.Errorf("CreateDeploymentRuleFileContent() stored content = %s, want %s", content, ruleContent)
+	}
+
+	// Creating the same rule file again must not overwrite it
+	err = client.CreateDeploymentRuleFileContent(context.Background(), deploymentID, ruleFileName, "groups: []\n")
+	var existsErr *RuleFileAlreadyExistsError
+	if !errors.As(err, &existsErr) {
+		t.Fatalf("CreateDeploymentRuleFileContent() error = %v, want RuleFileAlreadyExistsError", err)
+	}
+	if content, _ := fake.ruleFile(deploymentID, ruleFileName); content != ruleContent {
+		t.Errorf("CreateDeploymentRuleFileContent() overwrote the existing rule file")
+	}
+}
+
+func TestUpsertDeploymentRuleFile(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+
+	created, err := client.UpsertDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", "groups: []\n")
+	if err != nil {
+		t.Fatalf("UpsertDeploymentRuleFile() error = %v", err)
+	}
+	if !created {
+		t.Errorf("UpsertDeploymentRuleFile() created = false, want true")
+	}
+
+	created, err = client.UpsertDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", "groups:\n- name: a\n  rules: []\n")
+	if err != nil {
+		t.Fatalf("UpsertDeploymentRuleFile() error = %v", err)
+	}
+	if created {
+		t.Errorf("UpsertDeploymentRuleFile() created = true, want false")
+	}
+	if content, _ := fake.ruleFile(deploymentID, "rules.yml"); content != "groups:\n- name: a\n  rules: []\n" {
+		t.Errorf("UpsertDeploymentRuleFile() stored content = %q", content)
+	}
+
+	fake.failOn(http.MethodPost, "/api/v1/deployments/"+deploymentID+"/rule-sets/files/rules.yml", http.StatusInternalServerError)
+	if _, err := client.UpsertDeploymentRuleFile(context.Background(), deploymentID, "rules.yml", "groups: []\n"); err == nil {
+		t.Errorf("UpsertDeploymentRuleFile() error = nil, want error")
+	}
+}
+
+func TestUpdateDeploymentRuleFileContentExpectedVersion(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	path := "/api/v1/deployments/" + deploymentID + "/rule-sets/files/rules.yml"
+
+	for _, etags := range []bool{false, true} {
+		t.Run(fmt.Sprintf("etags=%v", etags), func(t *testing.T) {
+			fake, client := newFakeCloud(t)
+			fake.etags = etags
+			fake.addDeployment(DeploymentInfo{ID: deploymentID})
+			fake.addRuleFile(deploymentID, "rules.yml", "groups: []\n")
+			ctx := context.Background()
+
+			content, version, err := client.GetDeploymentRuleFileContentVersion(ctx, deploymentID, "rules.yml")
+			if err != nil {
+				t.Fatalf("GetDeploymentRuleFileContentVersion() error = %v", err)
+			}
+			if content != "groups: []\n" {
+				t.Errorf("GetDeploymentRuleFileContentVersion() content = %q", content)
+			}
+			if wantHash := !etags; strings.HasPrefix(version, "sha256:") != wantHash {
+				t.Errorf("GetDeploymentRuleFileContentVersion() version = %q", version)
+			}
+			if !etags && version != RuleFileContentVersion(content) {
+				t.Errorf("GetDeploymentRuleFileContentVersion() version = %q, want %q", version, RuleFileContentVersion(content))
+			}
+
+			// another writer modifies the rule file
+			fake.addRuleFile(deploymentID, "rules.yml", "groups:\n- name: other\n  rules: []\n")
+			err = client.UpdateDeploymentRuleFileContent(ctx, deploymentID, "rules.yml", "groups:\n- name: mine\n  rules: []\n", WithExpectedRuleFileVersion(version))
+			var conflictErr *RuleFileConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("UpdateDeploymentRuleFileContent() error = %v, want *RuleFileConflictError", err)
+			}
+			if conflictErr.ExpectedVersion != version || conflictErr.ActualVersion == "" || conflictErr.ActualVersion == version {
+				t.Errorf("UpdateDeploymentRuleFileContent() conflict = %+v", conflictErr)
+			}
+			if n := fake.requestCount(http.MethodPost, path); n != 0 {
+				t.Errorf("UpdateDeploymentRuleFileContent() uploaded the rule file %d times after conflict", n)
+			}
+
+			_, version, err = client.GetDeploymentRuleFileContentVersion(ctx, deploymentID, "rules.yml")
+			if err != nil {
+				t.Fatalf("GetDeploymentRuleFileContentVersion() error = %v", err)
+			}
+			if err := client.UpdateDeploymentRuleFileContent(ctx, deploymentID, "rules.yml", "groups: []\n", WithExpectedRuleFileVersion(version)); err != nil {
+				t.Fatalf("UpdateDeploymentRuleFileContent() error = %v", err)
+			}
+			if content, _ := fake.ruleFile(deploymentID, "rules.yml"); content != "groups: []\n" {
+				t.Errorf("UpdateDeploymentRuleFileContent() stored content = %q", content)
+			}
+			if etags && (len(fake.ifMatch) != 1 || fake.ifMatch[0] != version) {
+				t.Errorf("If-Match headers = %v, want [%s]", fake.ifMatch, version)
+			}
+			if !etags && len(fake.ifMatch) != 0 {
+				t.Errorf("If-Match headers = %v, want none for content hash versions", fake.ifMatch)
+			}
+
+			err = client.UpdateDeploymentRuleFileContent(ctx, deploymentID, "missing.yml", "groups: []\n", WithExpectedRuleFileVersion(version))
+			var notFoundErr *RuleFileNotFoundError
+			if !errors.As(err, &notFoundErr) {
+				t.Errorf("UpdateDeploymentRuleFileContent() for missing file error = %v, want *RuleFileNotFoundError", err)
+			}
+		})
+	}
+
+	t.Run("conflict detected by the API", func(t *testing.T) {
+		fake, client := newFakeCloud(t)
+		fake.etags = true
+		fake.addDeployment(DeploymentInfo{ID: deploymentID})
+		fake.addRuleFile(deploymentID, "rules.yml", "groups: []\n")
+		_, version, err := client.GetDeploymentRuleFileContentVersion(context.Background(), deploymentID, "rules.yml")
+		if err != nil {
+			t.Fatalf("GetDeploymentRuleFileContentVersion() error = %v", err)
+		}
+		// the rule file is modified between the re-read and the upload
+		fake.failOn(http.MethodPost, path, http.StatusPreconditionFailed)
+		err = client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, "rules.yml", "groups: []\n", WithExpectedRuleFileVersion(version))
+		var conflictErr *RuleFileConflictError
+		if !errors.As(err, &conflictErr) || conflictErr.ActualVersion != "" {
+			t.Errorf("UpdateDeploymentRuleFileContent() error = %v, want *RuleFileConflictError without actual version", err)
+		}
+	})
+}
+
+func TestRuleFileNameHandling(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "legacy rules?.yml", "groups: []\n")
+
+	// existing files with names that are not valid for new files are still accessible
+	content, err := client.GetDeploymentRuleFileContent(context.Background(), deploymentID, "legacy rules?.yml")
+	if err != nil {
+		t.Fatalf("GetDeploymentRuleFileContent() error = %v", err)
+	}
+	if content != "groups: []\n" {
+		t.Errorf("GetDeploymentRuleFileContent() = %q", content)
+	}
+	if err := client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, "legacy rules?.yml", "groups: [a]\n"); err != nil {
+		t.Fatalf("UpdateDeploymentRuleFileContent() error = %v", err)
+	}
+	created, err := client.UpsertDeploymentRuleFile(context.Background(), deploymentID, "legacy rules?.yml", "groups: [b]\n")
+	if err != nil || created {
+		t.Fatalf("UpsertDeploymentRuleFile() = %v, %v, want false, nil", created, err)
+	}
+	if content, _ := fake.ruleFile(deploymentID, "legacy rules?.yml"); content != "groups: [b]\n" {
+		t.Errorf("updated content = %q", content)
+	}
+	if err := client.DeleteDeploymentRuleFile(context.Background(), deploymentID, "legacy rules?.yml"); err != nil {
+		t.Fatalf("DeleteDeploymentRuleFile() error = %v", err)
+	}
+	if _, ok := fake.ruleFile(deploymentID, "legacy rules?.yml"); ok {
+		t.Errorf("DeleteDeploymentRuleFile() did not delete the rule file")
+	}
+
+	var validationErr *ValidationError
+	err = client.CreateDeploymentRuleFileContent(context.Background(), deploymentID, "../other/rules.yml", "groups: []\n")
+	if !errors.As(err, &validationErr) || validationErr.Field != "rule file name" {
+		t.Errorf("CreateDeploymentRuleFileContent() error = %v, want *ValidationError for rule file name", err)
+	}
+	// names of new files are checked strictly
+	if _, err := client.UpsertDeploymentRuleFile(context.Background(), deploymentID, "new rules?.yml", "groups: []\n"); !errors.As(err, &validationErr) {
+		t.Errorf("UpsertDeploymentRuleFile() for new file error = %v, want *ValidationError", err)
+	}
+	if _, ok := fake.ruleFile(deploymentID, "new rules?.yml"); ok {
+		t.Errorf("UpsertDeploymentRuleFile() created the rule file with invalid name")
+	}
+	if _, err := client.GetDeploymentRuleFileContent(context.Background(), deploymentID, ".."); !errors.As(err, &validationErr) {
+		t.Errorf("GetDeploymentRuleFileContent() error = %v, want *ValidationError", err)
+	}
+	if _, err := client.ListDeploymentRuleFileNames(context.Background(), "not-a-uuid"); !errors.As(err, &validationErr) || validationErr.Field != "deployment ID" {
+		t.Errorf("ListDeploymentRuleFileNames() error = %v, want *ValidationError for deployment ID", err)
+	}
 }
 
 func TestDeleteDeploymentRuleFile(t *testing.T) {
@@ -0,0 +1,338 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// RuleFileIssue - problem found in the alerting/recording rules file
+type RuleFileIssue struct {
+	// File - name of the rule file
+	File string `json:"file"`
+	// Line - 1-based line number of the problem (0 if unknown)
+	Line int `json:"line"`
+	// Column - 1-based column number of the problem (0 if unknown)
+	Column int `json:"column"`
+	// Message - description of the problem
+	Message string `json:"message"`
+}
+
+func (i RuleFileIssue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.File, i.Line, i.Column, i.Message)
+}
+
+// RuleFileValidationError is returned when the alerting/recording rules file is invalid
+type RuleFileValidationError struct {
+	// Issues - problems found in the rule file sorted by position
+	Issues []RuleFileIssue
+}
+
+func (e *RuleFileValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, issue.String())
+	}
+	return "invalid rule file: " + strings.Join(lines, "; ")
+}
+
+var (
+	ruleDurationRegex = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?(ms|s|m|h|d|w|y))+$`)
+	labelNameRegex    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	yamlErrorRegex    = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+)
+
+var (
+	ruleFileFields = map[string]bool{
+		"groups": true,
+	}
+	ruleGroupFields = map[string]bool{
+		"name": true, "interval": true, "eval_offset": true, "eval_delay": true, "limit": true, "type": true,
+		"concurrency": true, "labels": true, "params": true, "headers": true, "notifier_headers": true,
+		"rules": true, "debug": true, "eval_alignment": true,
+	}
+	ruleFields = map[string]bool{
+		"alert": true, "record": true, "expr": true, "for": true, "keep_firing_for": true,
+		"labels": true, "annotations": true, "debug": true, "update_entries_limit": true,
+	}
+	ruleGroupTypes = map[string]bool{
+		"prometheus": true, "graphite": true, "vlogs": true,
+	}
+)
+
+// ruleTemplateVariables defines variables available in alerting rule templates, so they can be parsed standalone.
+// It is kept on a single line, so line numbers reported by the template parser are not shifted.
+const ruleTemplateVariables = `{{$labels := .Labels}}{{$value := .Value}}{{$expr := .Expr}}{{$for := .For}}` +
+	`{{$externalLabels := .ExternalLabels}}{{$externalURL := .ExternalURL}}{{$alertID := .AlertID}}` +
+	`{{$groupID := .GroupID}}{{$activeAt := .ActiveAt}}{{$isPartial := .IsPartial}}`
+
+// ruleTemplateFuncs contains stubs of functions available in alerting rule templates.
+// Only names matter, since templates are parsed, but not executed.
+var ruleTemplateFuncs = func() template.FuncMap {
+	names := []string{
+		"args", "crlfEscape", "externalURL", "first", "graphLink", "htmlEscape", "humanize", "humanize1024",
+		"humanizeDuration", "humanizePercentage", "humanizeTimestamp", "jsonEscape", "label", "match", "now",
+		"parseDuration", "parseDurationTime", "pathEscape", "pathPrefix", "query", "queryEscape", "quotesEscape",
+		"reReplaceAll", "safeHtml", "sortByLabel", "strvalue", "stripDomain", "stripPort", "tableLink", "title",
+		"toDuration", "toLower", "toTime", "toUpper", "value",
+	}
+	funcs := make(template.FuncMap, len(names))
+	for _, name := range names {
+		funcs[name] = func(...any) any { return nil }
+	}
+	return funcs
+}()
+
+// RuleFileValidationOption enables additional checks in ValidateRuleFile
+type RuleFileValidationOption func(*ruleFileValidator)
+
+// WithRuleExprCheck enables the check of expr field of every rule.
+// Errors returned by check are reported as issues positioned at the expression.
+func WithRuleExprCheck(check func(expr string) error) RuleFileValidationOption {
+	return func(v *ruleFileValidator) {
+		v.checkExpr = check
+	}
+}
+
+// WithRecordingRuleNameCheck enables the check of record field of every recording rule.
+// Errors returned by check are reported as issues positioned at the recording rule name.
+func WithRecordingRuleNameCheck(check func(name string) error) RuleFileValidationOption {
+	return func(v *ruleFileValidator) {
+		v.checkRecordName = check
+	}
+}
+
+// ValidateRuleFile checks the structure of the alerting/recording rules file without sending it to VictoriaMetrics Cloud.
+// It checks YAML syntax, required fields, durations, duplicate group names, label names and annotation templates.
+// Additional checks can be enabled with options.
+// If the file is invalid, *RuleFileValidationError with positioned issues is returned.
+func ValidateRuleFile(name, content string, opts ...RuleFileValidationOption) error {
+	v := &ruleFileValidator{file: name}
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.validate(content)
+	if len(v.issues) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line != v.issues[j].Line {
+			return v.issues[i].Line < v.issues[j].Line
+		}
+		return v.issues[i].Column < v.issues[j].Column
+	})
+	return &RuleFileValidationError{Issues: v.issues}
+}
+
+type ruleFileValidator struct {
+	file   string
+	issues []RuleFileIssue
+
+	checkExpr       func(expr string) error
+	checkRecordName func(name string) error
+}
+
+func (v *ruleFileValidator) addIssue(node *yaml.Node, format string, args ...any) {
+	issue := RuleFileIssue{File: v.file, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	v.issues = append(v.issues, issue)
+}
+
+func (v *ruleFileValidator) validate(content string) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		issue := RuleFileIssue{File: v.file, Message: err.Error()}
+		if m := yamlErrorRegex.FindStringSubmatch(err.Error()); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = m[2]
+		}
+		v.issues = append(v.issues, issue)
+		return
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		v.addIssue(nil, "rule file is empty")
+		return
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		v.addIssue(root, "rule file must be a mapping with groups")
+		return
+	}
+	fields := v.mappingFields(root, "rule file", ruleFileFields)
+	groups, ok := fields["groups"]
+	if !ok {
+		v.addIssue(root, "missing required field groups")
+		return
+	}
+	if isNullNode(groups) {
+		return
+	}
+	if groups.Kind != yaml.SequenceNode {
+		v.addIssue(groups, "groups must be a list")
+		return
+	}
+	groupNames := make(map[string]int)
+	for _, group := range groups.Content {
+		v.validateGroup(group, groupNames)
+	}
+}
+
+func (v *ruleFileValidator) validateGroup(group *yaml.Node, groupNames map[string]int) {
+	if group.Kind != yaml.MappingNode {
+		v.addIssue(group, "group must be a mapping")
+		return
+	}
+	fields := v.mappingFields(group, "group", ruleGroupFields)
+	name := v.requiredString(group, fields, "name", "group")
+	if name != "" {
+		if line, ok := groupNames[name]; ok {
+			v.addIssue(fields["name"], "duplicate group name %q, previously defined at line %d", name, line)
+		} else {
+			groupNames[name] = fields["name"].Line
+		}
+	}
+	for _, field := range []string{"interval", "eval_offset", "eval_delay"} {
+		v.checkDuration(fields[field], field)
+	}
+	for _, field := range []string{"limit", "concurrency"} {
+		if node, ok := fields[field]; ok {
+			if n, err := strconv.Atoi(node.Value); node.Kind != yaml.ScalarNode || err != nil || n < 0 {
+				v.addIssue(node, "%s must be a non-negative integer, got %q", field, node.Value)
+			}
+		}
+	}
+	if node, ok := fields["type"]; ok && !ruleGroupTypes[node.Value] {
+		v.addIssue(node, "unsupported group type %q", node.Value)
+	}
+	v.checkLabels(fields["labels"], "labels", false)
+
+	rules, ok := fields["rules"]
+	if !ok || isNullNode(rules) {
+		return
+	}
+	if rules.Kind != yaml.SequenceNode {
+		v.addIssue(rules, "rules must be a list")
+		return
+	}
+	for _, rule := range rules.Content {
+		v.validateRule(rule)
+	}
+}
+
+func (v *ruleFileValidator) validateRule(rule *yaml.Node) {
+	if rule.Kind != yaml.MappingNode {
+		v.addIssue(rule, "rule must be a mapping")
+		return
+	}
+	fields := v.mappingFields(rule, "rule", ruleFields)
+	_, isAlert := fields["alert"]
+	record, isRecord := fields["record"]
+	switch {
+	case isAlert && isRecord:
+		v.addIssue(record, "rule must have either alert or record field, not both")
+	case !isAlert && !isRecord:
+		v.addIssue(rule, "rule must have either alert or record field")
+	case isAlert:
+		v.requiredString(rule, fields, "alert", "alerting rule")
+	default:
+		name := v.requiredString(rule, fields, "record", "recording rule")
+		if name != "" && v.checkRecordName != nil {
+			if err := v.checkRecordName(name); err != nil {
+				v.addIssue(record, "invalid recording rule name %q: %s", name, err)
+			}
+		}
+		for _, field := range []string{"for", "keep_firing_for", "annotations"} {
+			if node, ok := fields[field]; ok {
+				v.addIssue(node, "field %s is not allowed for recording rule %q", field, record.Value)
+			}
+		}
+	}
+	expr := v.requiredString(rule, fields, "expr", "rule")
+	if expr != "" && v.checkExpr != nil {
+		if err := v.checkExpr(expr); err != nil {
+			v.addIssue(fields["expr"], "invalid expression: %s", err)
+		}
+	}
+	v.checkDuration(fields["for"], "for")
+	v.checkDuration(fields["keep_firing_for"], "keep_firing_for")
+	v.checkLabels(fields["labels"], "labels", false)
+	if isAlert {
+		v.checkLabels(fields["annotations"], "annotations", true)
+	}
+}
+
+// mappingFields returns values of the mapping by keys and reports duplicate and unknown keys
+func (v *ruleFileValidator) mappingFields(node *yaml.Node, what string, known map[string]bool) map[string]*yaml.Node {
+	fields := make(map[string]*yaml.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if _, ok := fields[key.Value]; ok {
+			v.addIssue(key, "duplicate field %s in %s", key.Value, what)
+			continue
+		}
+		if known != nil && !known[key.Value] {
+			v.addIssue(key, "unknown field %s in %s", key.Value, what)
+		}
+		fields[key.Value] = value
+	}
+	return fields
+}
+
+func (v *ruleFileValidator) requiredString(parent *yaml.Node, fields map[string]*yaml.Node, field, what string) string {
+	node, ok := fields[field]
+	if !ok {
+		v.addIssue(parent, "missing required field %s in %s", field, what)
+		return ""
+	}
+	if node.Kind != yaml.ScalarNode || isNullNode(node) || strings.TrimSpace(node.Value) == "" {
+		v.addIssue(node, "field %s in %s must be a non-empty string", field, what)
+		return ""
+	}
+	return node.Value
+}
+
+func (v *ruleFileValidator) checkDuration(node *yaml.Node, field string) {
+	if node == nil {
+		return
+	}
+	if node.Kind != yaml.ScalarNode || !ruleDurationRegex.MatchString(node.Value) {
+		v.addIssue(node, "invalid %s duration %q, expected value like 30s, 5m or 1h30m", field, node.Value)
+	}
+}
+
+func (v *ruleFileValidator) checkLabels(node *yaml.Node, field string, isTemplate bool) {
+	if node == nil || isNullNode(node) {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, "%s must be a mapping", field)
+		return
+	}
+	fields := v.mappingFields(node, field, nil)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !labelNameRegex.MatchString(key.Value) {
+			v.addIssue(key, "invalid name %q in %s", key.Value, field)
+		}
+		if value.Kind != yaml.ScalarNode {
+			v.addIssue(value, "value of %q in %s must be a string", key.Value, field)
+			continue
+		}
+		if isTemplate && fields[key.Value] == value {
+			if _, err := template.New(key.Value).Funcs(ruleTemplateFuncs).Parse(ruleTemplateVariables + value.Value); err != nil {
+				v.addIssue(value, "invalid template in %s %q: %s", field, key.Value, err)
+			}
+		}
+	}
+}
+
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRuleFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantIssues []RuleFileIssue
+	}{
+		{
+			name: "valid file",
+			content: `groups:
+- name: example
+  interval: 1m
+  labels:
+    team: infra
+  rules:
+  - alert: HighRequestLatency
+    expr: job:request_latency_seconds:mean5m{job="myjob"} > 0.5
+    for: 10m
+    keep_firing_for: 1h30m
+    labels:
+      severity: page
+    annotations:
+      summary: "High request latency on {{ $labels.instance }}: {{ $value | humanize }}"
+  - record: job:request_latency_seconds:mean5m
+    expr: avg by (job) (request_latency_seconds)
+`,
+		},
+		{
+			name:    "empty groups",
+			content: "groups: []\n",
+		},
+		{
+			name:    "yaml syntax error",
+			content: "groups:\n- name: a\n  rules: [\n",
+			wantIssues: []RuleFileIssue{
+				{Line: 3, Message: "did not find expected node content"},
+			},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantIssues: []RuleFileIssue{
+				{Message: "rule file is empty"},
+			},
+		},
+		{
+			name:    "missing groups",
+			content: "rules: []\n",
+			wantIssues: []RuleFileIssue{
+				{Line: 1, Column: 1, Message: "unknown field rules in rule file"},
+				{Line: 1, Column: 1, Message: "missing required field groups"},
+			},
+		},
+		{
+			name: "group errors",
+			content: `groups:
+- name: a
+  interval: 5 minutes
+  rules: []
+- name: a
+  limit: -1
+  labels:
+    bad-name: x
+`,
+			wantIssues: []RuleFileIssue{
+				{Line: 3, Column: 13, Message: `invalid interval duration "5 minutes", expected value like 30s, 5m or 1h30m`},
+				{Line: 5, Column: 9, Message: `duplicate group name "a", previously defined at line 2`},
+				{Line: 6, Column: 10, Message: `limit must be a non-negative integer, got "-1"`},
+				{Line: 8, Column: 5, Message: `invalid name "bad-name" in labels`},
+			},
+		},
+		{
+			name: "rule errors",
+			content: `groups:
+- name: a
+  rules:
+  - alert: A
+    for: 5
+  - record: b
+    expr: up
+    for: 1m
+  - expr: up
+  - alert: C
+    record: c
+    expr: up
+    annotations:
+      summary: "{{ $labels.instance "
+      description: "{{ unknownFunc }}"
+  - alert: D
+    expr: up
+    lables: {}
+`,
+			wantIssues: []RuleFileIssue{
+				{Line: 4, Column: 5, Message: "missing required field expr in rule"},
+				{Line: 5, Column: 10, Message: `invalid for duration "5", expected value like 30s, 5m or 1h30m`},
+				{Line: 8, Column: 10, Message: `field for is not allowed for recording rule "b"`},
+				{Line: 9, Column: 5, Message: "rule must have either alert or record field"},
+				{Line: 11, Column: 13, Message: "rule must have either alert or record field, not both"},
+				{Line: 14, Column: 16, Message: `invalid template in annotations "summary"`},
+				{Line: 15, Column: 20, Message: `invalid template in annotations "description"`},
+				{Line: 18, Column: 5, Message: "unknown field lables in rule"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRuleFile("rules.yml", tt.content)
+			if len(tt.wantIssues) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateRuleFile() error = %v", err)
+				}
+				return
+			}
+			var validationErr *RuleFileValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidateRuleFile() error = %v, want RuleFileValidationError", err)
+			}
+			if len(validationErr.Issues) != len(tt.wantIssues) {
+				t.Fatalf("ValidateRuleFile() returned %d issues, want %d:\n%s", len(validationErr.Issues), len(tt.wantIssues), err)
+			}
+			for i, want := range tt.wantIssues {
+				got := validationErr.Issues[i]
+				if got.File != "rules.yml" || got.Line != want.Line || got.Column != want.Column || !strings.HasPrefix(got.Message, want.Message) {
+					t.Errorf("ValidateRuleFile() issue %d = %s, want %d:%d: %s", i, got, want.Line, want.Column, want.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRuleFilesValidation(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t, WithRuleFilesValidation())
+	fake.addDeployment(DeploymentInfo{ID: deploymentID})
+	fake.addRuleFile(deploymentID, "rules.yml", "groups: []\n")
+
+	var validationErr *RuleFileValidationError
+	err := client.CreateDeploymentRuleFileContent(context.Background(), deploymentID, "rules.yml", "groups: {}")
+	if !errors.As(err, &validationErr) {
+		t.Errorf("CreateDeploymentRuleFileContent() error = %v, want RuleFileValidationError", err)
+	}
+	err = client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, "rules.yml", "groups: {}")
+	if !errors.As(err, &validationErr) {
+		t.Errorf("UpdateDeploymentRuleFileContent() error = %v, want RuleFileValidationError", err)
+	}
+	if err := client.UpdateDeploymentRuleFileContent(context.Background(), deploymentID, "rules.yml", "groups: []"); err != nil {
+		t.Errorf("UpdateDeploymentRuleFileContent() error = %v", err)
+	}
+}
+
+func TestValidateRuleFile_Options(t *testing.T) {
+	content := `groups:
+- name: a
+  rules:
+  - record: "bad name"
+    expr: sum(rate(x[5m])
+  - alert: A
+    expr: up == 0
+`
+	opts := []RuleFileValidationOption{
+		WithRuleExprCheck(func(expr string) error {
+			if strings.Count(expr, "(") != strings.Count(expr, ")") {
+				return errors.New("unbalanced parentheses")
+			}
+			return nil
+		}),
+		WithRecordingRuleNameCheck(func(name string) error {
+			if strings.Contains(name, " ") {
+				return errors.New("metric name cannot contain spaces")
+			}
+			return nil
+		}),
+	}
+	if err := ValidateRuleFile("rules.yml", content); err != nil {
+		t.Fatalf("ValidateRuleFile() without options error = %v", err)
+	}
+	err := ValidateRuleFile("rules.yml", content, opts...)
+	var validationErr *RuleFileValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidateRuleFile() error = %v, want RuleFileValidationError", err)
+	}
+	want := []string{
+		`rules.yml:4:13: invalid recording rule name "bad name": metric name cannot contain spaces`,
+		`rules.yml:5:11: invalid expression: unbalanced parentheses`,
+	}
+	if len(validationErr.Issues) != len(want) {
+		t.Fatalf("ValidateRuleFile() returned %d issues, want %d:\n%s", len(validationErr.Issues), len(want), err)
+	}
+	for i := range want {
+		if got := validationErr.Issues[i].String(); got != want[i] {
+			t.Errorf("ValidateRuleFile() issue %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
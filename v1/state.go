@@ -0,0 +1,289 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StateVersion is the version of the state format written by this library
+const StateVersion = 1
+
+// State maps logical names used in configs to identifiers of VictoriaMetrics Cloud resources.
+// It allows higher-level tooling to stay idempotent across runs.
+type State struct {
+	// Version - version of the state format
+	Version int `json:"version"`
+	// Deployments - managed deployments by logical name
+	Deployments map[string]DeploymentState `json:"deployments"`
+}
+
+// DeploymentState - state of the managed deployment
+type DeploymentState struct {
+	// ID - unique identifier of the deployment
+	ID string `json:"id"`
+	// AccessTokens - identifiers of the managed access tokens by logical name
+	AccessTokens map[string]string `json:"access_tokens,omitempty"`
+	// LastApplied - the last applied configuration of the deployment (arbitrary JSON)
+	LastApplied json.RawMessage `json:"last_applied,omitempty"`
+	// LastAppliedAt - timestamp of the last apply
+	LastAppliedAt *time.Time `json:"last_applied_at,omitempty"`
+}
+
+// NewState returns an empty state
+func NewState() State {
+	return State{
+		Version:     StateVersion,
+		Deployments: make(map[string]DeploymentState),
+	}
+}
+
+// Deployment returns the state of the deployment by logical name
+func (s *State) Deployment(name string) (DeploymentState, bool) {
+	d, ok := s.Deployments[name]
+	return d, ok
+}
+
+// SetDeployment maps the logical name to the deployment ID.
+// Access tokens and last applied configuration are dropped if the deployment ID changes.
+func (s *State) SetDeployment(name, deploymentID string) {
+	if s.Deployments == nil {
+		s.Deployments = make(map[string]DeploymentState)
+	}
+	d := s.Deployments[name]
+	if d.ID != deploymentID {
+		d = DeploymentState{ID: deploymentID}
+	}
+	s.Deployments[name] = d
+}
+
+// SetAccessToken maps the logical name of the access token to the token ID within the deployment.
+func (s *State) SetAccessToken(deploymentName, tokenName, tokenID string) error {
+	d, ok := s.Deployments[deploymentName]
+	if !ok {
+		return fmt.Errorf("deployment %q is not found in state", deploymentName)
+	}
+	if d.AccessTokens == nil {
+		d.AccessTokens = make(map[string]string)
+	}
+	d.AccessTokens[tokenName] = tokenID
+	s.Deployments[deploymentName] = d
+	return nil
+}
+
+// SetLastApplied records the configuration applied to the deployment.
+func (s *State) SetLastApplied(deploymentName string, config any, appliedAt time.Time) error {
+	d, ok := s.Deployments[deploymentName]
+	if !ok {
+		return fmt.Errorf("deployment %q is not found in state", deploymentName)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal last applied configuration of deployment %q: %w", deploymentName, err)
+	}
+	d.LastApplied = data
+	d.LastAppliedAt = &appliedAt
+	s.Deployments[deploymentName] = d
+	return nil
+}
+
+// Forget removes the given resources from the state.
+func (s *State) Forget(resources []MissingStateResource) {
+	for _, r := range resources {
+		if r.AccessToken == "" {
+			delete(s.Deployments, r.Deployment)
+			continue
+		}
+		if d, ok := s.Deployments[r.Deployment]; ok {
+			delete(d.AccessTokens, r.AccessToken)
+		}
+	}
+}
+
+// StateStore persists the state between runs.
+type StateStore interface {
+	// Lock acquires the exclusive lock on the state and returns the function releasing it.
+	// It waits until the lock is released by other owner or ctx is done.
+	Lock(ctx context.Context) (unlock func() error, err error)
+	// Load returns the stored state or an empty state if it has not been saved yet.
+	Load(ctx context.Context) (State, error)
+	// Save stores the state.
+	Save(ctx context.Context, state State) error
+}
+
+// FileStateStore is a StateStore keeping the state in the local JSON file.
+// The lock is implemented with a lock file next to the state file, so it works across processes.
+type FileStateStore struct {
+	path         string
+	pollInterval time.Duration
+}
+
+var _ StateStore = (*FileStateStore)(nil)
+
+// NewFileStateStore creates a new FileStateStore for the state file at the given path.
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{
+		path:         path,
+		pollInterval: 100 * time.Millisecond,
+	}
+}
+
+// Path returns the path of the state file
+func (s *FileStateStore) Path() string {
+	return s.path
+}
+
+func (s *FileStateStore) lockPath() string {
+	return s.path + ".lock"
+}
+
+// Lock acquires the exclusive lock on the state file by creating the lock file next to it.
+// If the process holding the lock has crashed, the lock file must be removed manually.
+func (s *FileStateStore) Lock(ctx context.Context) (func() error, error) {
+	lockPath := s.lockPath()
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file %q: %w", lockPath, err)
+			}
+			return func() error {
+				if err := os.Remove(lockPath); err != nil {
+					return fmt.Errorf("failed to remove lock file %q: %w", lockPath, err)
+				}
+				return nil
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %q: %w", lockPath, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("state file %q is locked (lock file %q): %w", s.path, lockPath, ctx.Err())
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// Load reads the state from the file. An empty state is returned if the file doesn't exist.
+func (s *FileStateStore) Load(_ context.Context) (State, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read state file %q: %w", s.path, err)
+	}
+	state := NewState()
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to unmarshal state file %q: %w", s.path, err)
+	}
+	if state.Version > StateVersion {
+		return State{}, fmt.Errorf("unsupported state file %q version: %d, the latest supported version is %d", s.path, state.Version, StateVersion)
+	}
+	if state.Deployments == nil {
+		state.Deployments = make(map[string]DeploymentState)
+	}
+	return state, nil
+}
+
+// Save atomically writes the state to the file.
+func (s *FileStateStore) Save(_ context.Context, state State) error {
+	state.Version = StateVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write state file %q: %w", s.path, err)
+	}
+	return nil
+}
+
+// MissingStateResource - resource recorded in the state that doesn't exist in VictoriaMetrics Cloud anymore
+type MissingStateResource struct {
+	// Deployment - logical name of the deployment
+	Deployment string `json:"deployment"`
+	// AccessToken - logical name of the access token (empty if the whole deployment is missing)
+	AccessToken string `json:"access_token,omitempty"`
+	// ID - identifier of the missing resource
+	ID string `json:"id"`
+}
+
+// FindMissingStateResources returns deployments and access tokens recorded in the state
+// that have been deleted outside of the tooling managing the state.
+// The result is sorted by deployment and access token logical names.
+func (a *VMCloudAPIClient) FindMissingStateResources(ctx context.Context, state State) ([]MissingStateResource, error) {
+	deployments, err := a.ListDeployments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	existing := make(map[string]bool, len(deployments))
+	for _, d := range deployments {
+		existing[d.ID] = true
+	}
+
+	names := make([]string, 0, len(state.Deployments))
+	for name := range state.Deployments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []MissingStateResource
+	for _, name := range names {
+		d := state.Deployments[name]
+		if !existing[d.ID] {
+			result = append(result, MissingStateResource{Deployment: name, ID: d.ID})
+			continue
+		}
+		if len(d.AccessTokens) == 0 {
+			continue
+		}
+		tokens, err := a.ListDeploymentAccessTokens(ctx, d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list access tokens of deployment %q: %w", d.ID, err)
+		}
+		existingTokens := make(map[string]bool, len(tokens))
+		for _, token := range tokens {
+			existingTokens[token.ID] = true
+		}
+		tokenNames := make([]string, 0, len(d.AccessTokens))
+		for tokenName := range d.AccessTokens {
+			tokenNames = append(tokenNames, tokenName)
+		}
+		sort.Strings(tokenNames)
+		for _, tokenName := range tokenNames {
+			if tokenID := d.AccessTokens[tokenName]; !existingTokens[tokenID] {
+				result = append(result, MissingStateResource{Deployment: name, AccessToken: tokenName, ID: tokenID})
+			}
+		}
+	}
+	return result, nil
+}
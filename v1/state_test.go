@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStateStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path)
+
+	state, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(state.Deployments) != 0 || state.Version != StateVersion {
+		t.Fatalf("Load() of missing file = %+v, want empty state", state)
+	}
+
+	state.SetDeployment("prod", "123e4567-e89b-12d3-a456-426614174000")
+	if err := state.SetAccessToken("prod", "grafana", "token-1"); err != nil {
+		t.Fatalf("SetAccessToken() error = %v", err)
+	}
+	if err := state.SetAccessToken("missing", "grafana", "token-1"); err == nil {
+		t.Errorf("SetAccessToken() for unknown deployment should return an error")
+	}
+	appliedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := state.SetLastApplied("prod", map[string]int{"tier": 21}, appliedAt); err != nil {
+		t.Fatalf("SetLastApplied() error = %v", err)
+	}
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	d, ok := loaded.Deployment("prod")
+	if !ok {
+		t.Fatalf("Load() lost deployment prod")
+	}
+	if d.ID != "123e4567-e89b-12d3-a456-426614174000" || d.AccessTokens["grafana"] != "token-1" {
+		t.Errorf("Load() deployment = %+v", d)
+	}
+	var lastApplied map[string]int
+	if err := json.Unmarshal(d.LastApplied, &lastApplied); err != nil {
+		t.Fatalf("Failed to unmarshal last applied configuration: %v", err)
+	}
+	if lastApplied["tier"] != 21 || d.LastAppliedAt == nil || !d.LastAppliedAt.Equal(appliedAt) {
+		t.Errorf("Load() last applied = %s at %v", d.LastApplied, d.LastAppliedAt)
+	}
+
+	// Changing the deployment ID drops its access tokens
+	loaded.SetDeployment("prod", "223e4567-e89b-12d3-a456-426614174001")
+	if d, _ := loaded.Deployment("prod"); len(d.AccessTokens) != 0 || d.LastApplied != nil {
+		t.Errorf("SetDeployment() with new ID kept stale data: %+v", d)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 100}`), 0o600); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	if _, err := store.Load(ctx); err == nil {
+		t.Errorf("Load() should return an error for unsupported version")
+	}
+}
+
+func TestFileStateStore_Lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path)
+	store.pollInterval = time.Millisecond
+
+	unlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := NewFileStateStore(path).Lock(ctx); err == nil {
+		t.Fatalf("Lock() of already locked state should fail")
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+	unlock, err = store.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() after unlock error = %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+}
+
+func TestFindMissingStateResources(t *testing.T) {
+	existingID := "123e4567-e89b-12d3-a456-426614174000"
+	deletedID := "223e4567-e89b-12d3-a456-426614174001"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: existingID, Name: "prod"})
+	fake.addToken(existingID, AccessToken{ID: "token-1", Description: "grafana", Type: AccessModeRead})
+
+	state := NewState()
+	state.SetDeployment("prod", existingID)
+	state.SetDeployment("staging", deletedID)
+	_ = state.SetAccessToken("prod", "grafana", "token-1")
+	_ = state.SetAccessToken("prod", "vmagent", "token-2")
+
+	missing, err := client.FindMissingStateResources(context.Background(), state)
+	if err != nil {
+		t.Fatalf("FindMissingStateResources() error = %v", err)
+	}
+	want := []MissingStateResource{
+		{Deployment: "prod", AccessToken: "vmagent", ID: "token-2"},
+		{Deployment: "staging", ID: deletedID},
+	}
+	if len(missing) != len(want) {
+		t.Fatalf("FindMissingStateResources() = %+v, want %+v", missing, want)
+	}
+	for i := range want {
+		if missing[i] != want[i] {
+			t.Errorf("FindMissingStateResources()[%d] = %+v, want %+v", i, missing[i], want[i])
+		}
+	}
+
+	state.Forget(missing)
+	if _, ok := state.Deployment("staging"); ok {
+		t.Errorf("Forget() did not remove deployment staging")
+	}
+	if d, _ := state.Deployment("prod"); len(d.AccessTokens) != 1 {
+		t.Errorf("Forget() access tokens = %v, want only grafana", d.AccessTokens)
+	}
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TenantID - identifier of the tenant of the cluster deployment consisting of account ID and project ID.
+// See https://docs.victoriametrics.com/cluster-victoriametrics/#multitenancy
+type TenantID struct {
+	accountID uint32
+	projectID uint32
+}
+
+// NewTenantID returns the tenant ID with the given account ID and project ID
+func NewTenantID(accountID, projectID uint32) TenantID {
+	return TenantID{accountID: accountID, projectID: projectID}
+}
+
+// ParseTenantID parses the tenant ID in <accountID> or <accountID>:<projectID> form,
+// where both parts are 32-bit unsigned integers. Project ID is 0 if omitted.
+// It returns *ValidationError if the tenant ID is invalid.
+func ParseTenantID(s string) (TenantID, error) {
+	newErr := func(reason string) error {
+		return &ValidationError{Field: "tenant ID", Value: s, Reason: reason}
+	}
+	if s == "" {
+		return TenantID{}, newErr("cannot be empty")
+	}
+	accountPart, projectPart, hasProject := strings.Cut(s, ":")
+	accountID, err := parseTenantIDPart(accountPart)
+	if err != nil {
+		return TenantID{}, newErr("account ID " + err.Error() + ", expected <accountID> or <accountID>:<projectID>")
+	}
+	var projectID uint32
+	if hasProject {
+		projectID, err = parseTenantIDPart(projectPart)
+		if err != nil {
+			return TenantID{}, newErr("project ID " + err.Error() + ", expected <accountID> or <accountID>:<projectID>")
+		}
+	}
+	return TenantID{accountID: accountID, projectID: projectID}, nil
+}
+
+func parseTenantIDPart(s string) (uint32, error) {
+	// strconv.ParseUint accepts signs and underscores in some forms, so digits are checked explicitly
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("must be a number")
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("must be in range [0..%d]", uint32(1<<32-1))
+	}
+	return uint32(n), nil
+}
+
+// AccountID returns the account ID of the tenant
+func (t TenantID) AccountID() uint32 {
+	return t.accountID
+}
+
+// ProjectID returns the project ID of the tenant
+func (t TenantID) ProjectID() uint32 {
+	return t.projectID
+}
+
+// String returns the tenant ID in <accountID>:<projectID> form
+func (t TenantID) String() string {
+	return strconv.FormatUint(uint64(t.accountID), 10) + ":" + strconv.FormatUint(uint64(t.projectID), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t TenantID) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *TenantID) UnmarshalText(text []byte) error {
+	parsed, err := ParseTenantID(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// InsertURL returns the URL of the data ingestion API of the cluster deployment for the tenant:
+// <AccessEndpoint>/insert/<accountID>:<projectID>/<path...>, e.g. InsertURL(tenant, "prometheus/api/v1/write").
+func (d DeploymentInfo) InsertURL(tenant TenantID, path ...string) (string, error) {
+	return d.clusterURL("insert", tenant, path)
+}
+
+// SelectURL returns the URL of the querying API of the cluster deployment for the tenant:
+// <AccessEndpoint>/select/<accountID>:<projectID>/<path...>, e.g. SelectURL(tenant, "prometheus").
+func (d DeploymentInfo) SelectURL(tenant TenantID, path ...string) (string, error) {
+	return d.clusterURL("select", tenant, path)
+}
+
+func (d DeploymentInfo) clusterURL(component string, tenant TenantID, path []string) (string, error) {
+	if err := d.checkTenantsSupported(); err != nil {
+		return "", err
+	}
+	endpoint, err := d.accessEndpointURL()
+	if err != nil {
+		return "", err
+	}
+	return endpoint.JoinPath(append([]string{component, tenant.String()}, path...)...).String(), nil
+}
+
+// tenant returns the tenant of the access token, tenant 0:0 is used if the token has no tenant
+func (t AccessToken) tenant() (TenantID, error) {
+	if t.TenantID == "" {
+		return TenantID{}, nil
+	}
+	return ParseTenantID(t.TenantID)
+}
+
+// checkTenantsSupported returns an error if the deployment doesn't support tenants
+func (d DeploymentInfo) checkTenantsSupported() error {
+	if d.Type != DeploymentTypeCluster {
+		return fmt.Errorf("deployment %q of type %q doesn't support tenants, only %q deployments do", d.ID, d.Type, DeploymentTypeCluster)
+	}
+	return nil
+}
+
+// accessEndpointURL parses AccessEndpoint of the deployment
+func (d DeploymentInfo) accessEndpointURL() (*url.URL, error) {
+	if d.AccessEndpoint == "" {
+		return nil, fmt.Errorf("deployment %q has no access endpoint", d.ID)
+	}
+	endpoint, err := url.Parse(d.AccessEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access endpoint %q of deployment %q: %w", d.AccessEndpoint, d.ID, err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid access endpoint %q of deployment %q: expected absolute URL", d.AccessEndpoint, d.ID)
+	}
+	return endpoint, nil
+}
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseTenantID(t *testing.T) {
+	tests := []struct {
+		name      string
+		tenantID  string
+		accountID uint32
+		projectID uint32
+		wantErr   bool
+	}{
+		{name: "account only", tenantID: "12", accountID: 12},
+		{name: "account and project", tenantID: "12:34", accountID: 12, projectID: 34},
+		{name: "max values", tenantID: "4294967295:4294967295", accountID: 4294967295, projectID: 4294967295},
+		{name: "empty", tenantID: "", wantErr: true},
+		{name: "account out of range", tenantID: "4294967296", wantErr: true},
+		{name: "project out of range", tenantID: "1:4294967296", wantErr: true},
+		{name: "negative", tenantID: "-1", wantErr: true},
+		{name: "sign", tenantID: "+1:2", wantErr: true},
+		{name: "empty project", tenantID: "1:", wantErr: true},
+		{name: "extra part", tenantID: "1:2:3", wantErr: true},
+		{name: "not a number", tenantID: "abc", wantErr: true},
+		{name: "large account and project", tenantID: "12345:67890", accountID: 12345, projectID: 67890},
+		{name: "not a number with hyphens", tenantID: "not-a-number", wantErr: true},
+		{name: "project not a number", tenantID: "12345:abcde", wantErr: true},
+		{name: "account out of range with project", tenantID: "4294967296:0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTenantID(tt.tenantID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTenantID(%q) error = %v, wantErr %v", tt.tenantID, err, tt.wantErr)
+			}
+			if err != nil {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("ParseTenantID(%q) error type = %T, want *ValidationError", tt.tenantID, err)
+				}
+				return
+			}
+			if got.AccountID() != tt.accountID || got.ProjectID() != tt.projectID {
+				t.Errorf("ParseTenantID(%q) = %d:%d, want %d:%d", tt.tenantID, got.AccountID(), got.ProjectID(), tt.accountID, tt.projectID)
+			}
+		})
+	}
+}
+
+func TestTenantIDText(t *testing.T) {
+	tenant := NewTenantID(12, 0)
+	if tenant.String() != "12:0" {
+		t.Errorf("String() = %q, want 12:0", tenant.String())
+	}
+	data, err := json.Marshal(map[string]TenantID{"tenant": tenant})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"tenant":"12:0"}` {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+	var decoded struct {
+		Tenant TenantID `json:"tenant"`
+	}
+	if err := json.Unmarshal([]byte(`{"tenant":"7:8"}`), &decoded); err != nil || decoded.Tenant != NewTenantID(7, 8) {
+		t.Errorf("json.Unmarshal() = %v, error = %v", decoded.Tenant, err)
+	}
+	if err := json.Unmarshal([]byte(`{"tenant":"7:x"}`), &decoded); err == nil {
+		t.Errorf("json.Unmarshal() with invalid tenant error = nil, want error")
+	}
+}
+
+func TestDeploymentInfoClusterURLs(t *testing.T) {
+	tenant := NewTenantID(1, 2)
+	d := DeploymentInfo{ID: "d1", Type: DeploymentTypeCluster, AccessEndpoint: "https://cluster.example.com/"}
+
+	insertURL, err := d.InsertURL(tenant, "prometheus/api/v1/write")
+	if err != nil {
+		t.Fatalf("InsertURL() error = %v", err)
+	}
+	if insertURL != "https://cluster.example.com/insert/1:2/prometheus/api/v1/write" {
+		t.Errorf("InsertURL() = %q", insertURL)
+	}
+	selectURL, err := d.SelectURL(tenant)
+	if err != nil {
+		t.Fatalf("SelectURL() error = %v", err)
+	}
+	if selectURL != "https://cluster.example.com/select/1:2" {
+		t.Errorf("SelectURL() = %q", selectURL)
+	}
+
+	single := DeploymentInfo{ID: "d2", Type: DeploymentTypeSingleNode, AccessEndpoint: "https://single.example.com"}
+	if _, err := single.InsertURL(tenant); err == nil {
+		t.Errorf("InsertURL() for single-node deployment error = nil, want error")
+	}
+	if _, err := (DeploymentInfo{Type: DeploymentTypeCluster}).SelectURL(tenant); err == nil {
+		t.Errorf("SelectURL() without access endpoint error = nil, want error")
+	}
+	if _, err := (DeploymentInfo{Type: DeploymentTypeCluster, AccessEndpoint: "cluster.example.com"}).SelectURL(tenant); err == nil {
+		t.Errorf("SelectURL() with relative access endpoint error = nil, want error")
+	}
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TransactionUndoFunc undoes the changes made by a successful transaction step
+type TransactionUndoFunc func(ctx context.Context) error
+
+// TransactionStep - single step of the transaction
+type TransactionStep struct {
+	// Name - human-readable name of the step used in the report
+	Name string
+	// Run performs the step and returns the compensation undoing it (nil if there is nothing to undo)
+	Run func(ctx context.Context) (TransactionUndoFunc, error)
+}
+
+// TransactionStepStatus - status of the transaction step
+type TransactionStepStatus string
+
+const (
+	// TransactionStepStatusDone - step has been performed and hasn't been rolled back
+	TransactionStepStatusDone TransactionStepStatus = "done"
+	// TransactionStepStatusFailed - step has failed
+	TransactionStepStatusFailed TransactionStepStatus = "failed"
+	// TransactionStepStatusUndone - step has been performed and rolled back
+	TransactionStepStatusUndone TransactionStepStatus = "undone"
+	// TransactionStepStatusUndoFailed - step has been performed, but its rollback has failed
+	TransactionStepStatusUndoFailed TransactionStepStatus = "undo_failed"
+)
+
+func (s TransactionStepStatus) String() string {
+	return string(s)
+}
+
+// TransactionStepResult - result of the transaction step
+type TransactionStepResult struct {
+	// Name - name of the step
+	Name string `json:"name"`
+	// Status - status of the step
+	Status TransactionStepStatus `json:"status"`
+	// Error - error of the step or its rollback (empty on success)
+	Error string `json:"error,omitempty"`
+}
+
+// TransactionReport - result of RunTransaction
+type TransactionReport struct {
+	// Steps - results of performed steps in the order of execution; steps after the failed one are not included
+	Steps []TransactionStepResult `json:"steps"`
+	// RolledBack is true if the transaction has failed and the rollback has been performed
+	RolledBack bool `json:"rolled_back"`
+}
+
+// RunTransaction performs the steps one by one.
+// If a step fails, compensations of all previously performed steps are called in reverse order
+// and the error of the failed step is returned together with rollback errors.
+// Compensations are called with a context that is not canceled together with ctx,
+// so the rollback is performed even if the transaction is interrupted by ctx cancellation.
+func RunTransaction(ctx context.Context, steps ...TransactionStep) (TransactionReport, error) {
+	var report TransactionReport
+	undos := make([]TransactionUndoFunc, 0, len(steps))
+	for _, step := range steps {
+		undo, err := step.Run(ctx)
+		if err == nil {
+			report.Steps = append(report.Steps, TransactionStepResult{Name: step.Name, Status: TransactionStepStatusDone})
+			undos = append(undos, undo)
+			continue
+		}
+		report.Steps = append(report.Steps, TransactionStepResult{Name: step.Name, Status: TransactionStepStatusFailed, Error: err.Error()})
+		errs := []error{fmt.Errorf("transaction step %q failed: %w", step.Name, err)}
+		errs = append(errs, rollbackTransaction(context.WithoutCancel(ctx), undos, &report)...)
+		return report, errors.Join(errs...)
+	}
+	return report, nil
+}
+
+func rollbackTransaction(ctx context.Context, undos []TransactionUndoFunc, report *TransactionReport) []error {
+	report.RolledBack = true
+	var errs []error
+	for i, undo := range slices.Backward(undos) {
+		step := &report.Steps[i]
+		if undo == nil {
+			step.Status = TransactionStepStatusUndone
+			continue
+		}
+		if err := undo(ctx); err != nil {
+			step.Status = TransactionStepStatusUndoFailed
+			step.Error = err.Error()
+			errs = append(errs, fmt.Errorf("rollback of transaction step %q failed: %w", step.Name, err))
+			continue
+		}
+		step.Status = TransactionStepStatusUndone
+	}
+	return errs
+}
+
+// CreateDeploymentStep returns the transaction step creating a deployment.
+// The created deployment is stored into out, so it can be referenced by the next steps.
+// The rollback deletes the created deployment.
+func (a *VMCloudAPIClient) CreateDeploymentStep(request DeploymentCreationRequest, out *DeploymentInfo) TransactionStep {
+	return TransactionStep{
+		Name: fmt.Sprintf("create deployment %q", request.Name),
+		Run: func(ctx context.Context) (TransactionUndoFunc, error) {
+			created, err := a.CreateDeployment(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			*out = created
+			return func(ctx context.Context) error {
+				return a.DeleteDeployment(ctx, created.ID)
+			}, nil
+		},
+	}
+}
+
+// CreateDeploymentAccessTokenStep returns the transaction step creating an access token for the deployment.
+// The deployment is read when the step is performed, so it can be filled by the previous CreateDeploymentStep.
+// The created token is stored into out. The rollback deletes the created token.
+func (a *VMCloudAPIClient) CreateDeploymentAccessTokenStep(deployment *DeploymentInfo, request AccessTokenCreateRequest, out *AccessToken) TransactionStep {
+	return TransactionStep{
+		Name: fmt.Sprintf("create access token %q", request.Description),
+		Run: func(ctx context.Context) (TransactionUndoFunc, error) {
+			deploymentID := deployment.ID
+			created, err := a.CreateDeploymentAccessToken(ctx, deploymentID, request)
+			if err != nil {
+				return nil, err
+			}
+			if out != nil {
+				*out = created
+			}
+			return func(ctx context.Context) error {
+				return a.DeleteDeploymentAccessToken(ctx, deploymentID, created.ID)
+			}, nil
+		},
+	}
+}
+
+// UploadDeploymentRuleFileStep returns the transaction step uploading an alerting/recording rules file to the deployment.
+// The deployment is read when the step is performed, so it can be filled by the previous CreateDeploymentStep.
+// The rollback restores the previous content of the rule file without validation or deletes the file if it didn't exist.
+func (a *VMCloudAPIClient) UploadDeploymentRuleFileStep(deployment *DeploymentInfo, ruleFileName, content string) TransactionStep {
+	return TransactionStep{
+		Name: fmt.Sprintf("upload rule file %q", ruleFileName),
+		Run: func(ctx context.Context) (TransactionUndoFunc, error) {
+			deploymentID := deployment.ID
+			names, err := a.ListDeploymentRuleFileNames(ctx, deploymentID)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(names, ruleFileName) {
+				if err := a.putRuleFile(ctx, deploymentID, ruleFileName, content, true); err != nil {
+					return nil, err
+				}
+				return func(ctx context.Context) error {
+					return a.DeleteDeploymentRuleFile(ctx, deploymentID, ruleFileName)
+				}, nil
+			}
+			previous, err := a.GetDeploymentRuleFileContent(ctx, deploymentID, ruleFileName)
+			if err != nil {
+				return nil, err
+			}
+			if err := a.putRuleFile(ctx, deploymentID, ruleFileName, content, false); err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) error {
+				// the previous content is restored as is, even if it doesn't pass the current validation
+				if err := a.uploadDeploymentRuleFile(ctx, deploymentID, ruleFileName, previous); err != nil {
+					return fmt.Errorf("failed to restore rule file %q for deployment %q: %w", ruleFileName, deploymentID, err)
+				}
+				return nil
+			}, nil
+		},
+	}
+}
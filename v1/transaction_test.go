@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRunTransaction(t *testing.T) {
+	var calls []string
+	step := func(name string, fail, failUndo bool) TransactionStep {
+		return TransactionStep{
+			Name: name,
+			Run: func(context.Context) (TransactionUndoFunc, error) {
+				calls = append(calls, "run "+name)
+				if fail {
+					return nil, errors.New("step error")
+				}
+				return func(context.Context) error {
+					calls = append(calls, "undo "+name)
+					if failUndo {
+						return errors.New("undo error")
+					}
+					return nil
+				}, nil
+			},
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		calls = nil
+		report, err := RunTransaction(context.Background(), step("a", false, false), step("b", false, false))
+		if err != nil {
+			t.Fatalf("RunTransaction() error = %v", err)
+		}
+		if report.RolledBack || len(report.Steps) != 2 || report.Steps[1].Status != TransactionStepStatusDone {
+			t.Errorf("RunTransaction() report = %+v", report)
+		}
+		if len(calls) != 2 {
+			t.Errorf("RunTransaction() calls = %v", calls)
+		}
+	})
+
+	t.Run("rollback in reverse order", func(t *testing.T) {
+		calls = nil
+		report, err := RunTransaction(context.Background(),
+			step("a", false, false),
+			step("b", false, true),
+			step("c", true, false),
+			step("d", false, false),
+		)
+		if err == nil {
+			t.Fatalf("RunTransaction() should return an error")
+		}
+		wantCalls := []string{"run a", "run b", "run c", "undo b", "undo a"}
+		if len(calls) != len(wantCalls) {
+			t.Fatalf("RunTransaction() calls = %v, want %v", calls, wantCalls)
+		}
+		for i := range wantCalls {
+			if calls[i] != wantCalls[i] {
+				t.Errorf("RunTransaction() call %d = %s, want %s", i, calls[i], wantCalls[i])
+			}
+		}
+		wantStatuses := []TransactionStepStatus{TransactionStepStatusUndone, TransactionStepStatusUndoFailed, TransactionStepStatusFailed}
+		if !report.RolledBack || len(report.Steps) != len(wantStatuses) {
+			t.Fatalf("RunTransaction() report = %+v", report)
+		}
+		for i := range wantStatuses {
+			if report.Steps[i].Status != wantStatuses[i] {
+				t.Errorf("RunTransaction() step %d status = %s, want %s", i, report.Steps[i].Status, wantStatuses[i])
+			}
+		}
+	})
+}
+
+func TestRunTransaction_ClientSteps(t *testing.T) {
+	existingID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t)
+	fake.addDeployment(DeploymentInfo{ID: existingID, Name: "existing"})
+	fake.addRuleFile(existingID, "existing.yml", "groups: []\n")
+
+	var created DeploymentInfo
+	var token AccessToken
+	existing := &DeploymentInfo{ID: existingID}
+	steps := []TransactionStep{
+		client.UploadDeploymentRuleFileStep(existing, "existing.yml", "groups: [changed]\n"),
+		client.UploadDeploymentRuleFileStep(existing, "new.yml", "groups: []\n"),
+		client.CreateDeploymentStep(DeploymentCreationRequest{
+			Name:              "new",
+			Type:              DeploymentTypeSingleNode,
+			Provider:          DeploymentCloudProviderAWS,
+			Region:            "us-east-2",
+			Tier:              21,
+			StorageSize:       10,
+			StorageSizeUnit:   StorageUnitGB,
+			Retention:         30,
+			RetentionUnit:     DurationUnitDay,
+			DeduplicationUnit: DurationUnitSecond,
+			MaintenanceWindow: MaintenanceWindowWeekendDays,
+		}, &created),
+		client.CreateDeploymentAccessTokenStep(&created, AccessTokenCreateRequest{Description: "vmagent", Type: AccessModeWrite}, &token),
+		client.UploadDeploymentRuleFileStep(&created, "rules.yml", "groups: []\n"),
+	}
+	// fail the last step after the deployment and token have been created
+	fake.failOn(http.MethodGet, "/api/v1/deployments/00000000-0000-0000-0000-000000000001/rule-sets/files", http.StatusInternalServerError)
+
+	report, err := RunTransaction(context.Background(), steps...)
+	if err == nil {
+		t.Fatalf("RunTransaction() should return an error")
+	}
+	if !report.RolledBack || len(report.Steps) != 5 {
+		t.Fatalf("RunTransaction() report = %+v", report)
+	}
+	for i, step := range report.Steps[:4] {
+		if step.Status != TransactionStepStatusUndone {
+			t.Errorf("RunTransaction() step %d status = %s, want undone: %s", i, step.Status, step.Error)
+		}
+	}
+	if created.ID == "" || token.ID == "" {
+		t.Fatalf("RunTransaction() did not fill created resources")
+	}
+	if fake.requestCount(http.MethodDelete, "/api/v1/deployments/"+created.ID+"/access_tokens/"+token.ID) != 1 {
+		t.Errorf("RunTransaction() did not delete created access token")
+	}
+	if fake.requestCount(http.MethodDelete, "/api/v1/deployments/"+created.ID) != 1 {
+		t.Errorf("RunTransaction() did not delete created deployment")
+	}
+	if _, ok := fake.ruleFile(existingID, "new.yml"); ok {
+		t.Errorf("RunTransaction() did not delete uploaded rule file")
+	}
+	if content, _ := fake.ruleFile(existingID, "existing.yml"); content != "groups: []\n" {
+		t.Errorf("RunTransaction() did not restore rule file content: %q", content)
+	}
+}
+
+func TestRunTransaction_RestoresInvalidRuleFile(t *testing.T) {
+	deploymentID := "123e4567-e89b-12d3-a456-426614174000"
+	fake, client := newFakeCloud(t, WithRuleFilesValidation())
+	fake.addDeployment(DeploymentInfo{ID: deploymentID, Name: "existing"})
+	// the content had been uploaded before the validation was enabled
+	legacy := "groups:\n  - name: legacy\n    rules:\n      - record: \"invalid name\"\n"
+	fake.addRuleFile(deploymentID, "legacy.yml", legacy)
+
+	steps := []TransactionStep{
+		client.UploadDeploymentRuleFileStep(&DeploymentInfo{ID: deploymentID}, "legacy.yml", "groups: []\n"),
+		{Name: "fail", Run: func(context.Context) (TransactionUndoFunc, error) {
+			return nil, errors.New("step failed")
+		}},
+	}
+	report, err := RunTransaction(context.Background(), steps...)
+	if err == nil {
+		t.Fatalf("RunTransaction() should return an error")
+	}
+	if report.Steps[0].Status != TransactionStepStatusUndone {
+		t.Errorf("RunTransaction() upload step status = %s, want undone: %s", report.Steps[0].Status, report.Steps[0].Error)
+	}
+	if content, _ := fake.ruleFile(deploymentID, "legacy.yml"); content != legacy {
+		t.Errorf("RunTransaction() did not restore rule file content: %q", content)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
 type apiKeyContextKeyType string
@@ -17,11 +18,23 @@ func ContextWithDynamicAPIKey(ctx context.Context, apiKey string) context.Contex
 }
 
 func requestAPI[R any](ctx context.Context, a *VMCloudAPIClient, method string, body io.Reader, path ...string) (R, error) {
+	result, _, err := requestAPIWithHeaders[R](ctx, a, method, nil, body, path...)
+	return result, err
+}
+
+// requestAPIWithHeaders is like requestAPI, but sends additional request headers and returns response headers
+func requestAPIWithHeaders[R any](ctx context.Context, a *VMCloudAPIClient, method string, header http.Header, body io.Reader, path ...string) (R, http.Header, error) {
 	var result R
-	reqURL := a.parsedURL.JoinPath(path...).String()
+	reqURL, err := buildRequestURL(a.parsedURL, path...)
+	if err != nil {
+		return result, nil, err
+	}
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
-		return result, fmt.Errorf("failed to create request: %w", err)
+		return result, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
 	}
 	apiKey := a.apiKey
 	if apiKey == "" {
@@ -32,17 +45,17 @@ func requestAPI[R any](ctx context.Context, a *VMCloudAPIClient, method string,
 	req.Header.Set(AccessTokenHeader, apiKey)
 	resp, err := a.c.Do(req)
 	if err != nil {
-		return result, fmt.Errorf("failed to send request: %w", err)
+		return result, nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 	respBodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return result, fmt.Errorf("failed to read response body: %w", err)
+		return result, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 	if resp.StatusCode/100 != 2 {
-		return result, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBodyBytes))
+		return result, resp.Header, &APIError{StatusCode: resp.StatusCode, Body: string(respBodyBytes)}
 	}
 	if len(respBodyBytes) > 0 {
 		// Special case for string type - just return the response body as a string
@@ -51,9 +64,26 @@ func requestAPI[R any](ctx context.Context, a *VMCloudAPIClient, method string,
 		} else {
 			// For other types, unmarshal as JSON
 			if err = json.Unmarshal(respBodyBytes, &result); err != nil {
-				return result, fmt.Errorf("failed to unmarshal response body: %w", err)
+				return result, resp.Header, fmt.Errorf("failed to unmarshal response body: %w", err)
 			}
 		}
 	}
-	return result, nil
+	return result, resp.Header, nil
+}
+
+// buildRequestURL joins the API route given as the first element of path with the rest of path elements.
+// Elements after the route are user-supplied values, so each of them is escaped as a single path segment.
+func buildRequestURL(base *url.URL, path ...string) (string, error) {
+	if len(path) == 0 {
+		return base.String(), nil
+	}
+	elems := make([]string, 0, len(path))
+	elems = append(elems, path[0])
+	for _, segment := range path[1:] {
+		if err := checkPathParam("path segment", segment); err != nil {
+			return "", err
+		}
+		elems = append(elems, url.PathEscape(segment))
+	}
+	return base.JoinPath(elems...).String(), nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -179,43 +180,52 @@ func strPtr(s string) *string {
 	return &s
 }
 
-func TestIsValidTenantID(t *testing.T) {
+func TestBuildRequestURL(t *testing.T) {
+	base, err := url.Parse("https://api.example.com")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
 	tests := []struct {
-		name     string
-		tenantID string
-		want     bool
+		name    string
+		path    []string
+		want    string
+		wantErr bool
 	}{
 		{
-			name:     "valid tenant ID - simple number",
-			tenantID: "12345",
-			want:     true,
+			name: "route only",
+			path: []string{"/api/v1/deployments"},
+			want: "https://api.example.com/api/v1/deployments",
 		},
 		{
-			name:     "valid tenant ID - with colon and number",
-			tenantID: "12345:67890",
-			want:     true,
+			name: "route with segments",
+			path: []string{"/api/v1/deployments", "123", "rule-sets", "files", "alerts.yml"},
+			want: "https://api.example.com/api/v1/deployments/123/rule-sets/files/alerts.yml",
 		},
 		{
-			name:     "invalid tenant ID - empty string",
-			tenantID: "",
-			want:     false,
+			name: "segment is escaped",
+			path: []string{"/api/v1/deployments", "a b/c?%#"},
+			want: "https://api.example.com/api/v1/deployments/a%20b%2Fc%3F%25%23",
 		},
 		{
-			name:     "invalid tenant ID - not a number",
-			tenantID: "not-a-number",
-			want:     false,
+			name:    "empty segment",
+			path:    []string{"/api/v1/deployments", ""},
+			wantErr: true,
 		},
 		{
-			name:     "invalid tenant ID - wrong format",
-			tenantID: "12345:abcde",
-			want:     false,
+			name:    "parent directory segment",
+			path:    []string{"/api/v1/deployments", ".."},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isValidTenantID(tt.tenantID); got != tt.want {
-				t.Errorf("isValidTenantID() = %v, want %v", got, tt.want)
+			got, err := buildRequestURL(base, tt.path...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildRequestURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildRequestURL() = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -2,10 +2,31 @@ package v1
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 )
 
-var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+// MaxRuleFileNameLength - maximum length of the alerting/recording rules file name
+const MaxRuleFileNameLength = 128
+
+var (
+	uuidRegex         = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	ruleFileNameRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// ValidationError is returned when a user-supplied parameter is invalid
+type ValidationError struct {
+	// Field - name of the invalid parameter
+	Field string
+	// Value - value of the invalid parameter
+	Value string
+	// Reason - description of the violated constraint
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
 
 func isValidUUID(uuid string) bool {
 	return uuidRegex.MatchString(uuid)
@@ -13,18 +34,59 @@ func isValidUUID(uuid string) bool {
 
 func checkDeploymentID(deploymentID string) error {
 	if deploymentID == "" {
-		return fmt.Errorf("deployment ID cannot be empty")
+		return &ValidationError{Field: "deployment ID", Value: deploymentID, Reason: "cannot be empty"}
 	}
 	if !isValidUUID(deploymentID) {
-		return fmt.Errorf("invalid deployment ID format: %s", deploymentID)
+		return &ValidationError{Field: "deployment ID", Value: deploymentID, Reason: "must be a UUID"}
+	}
+	return nil
+}
+
+// checkAccessTokenCreateRequest checks the access token creation request before sending it to the API
+func checkAccessTokenCreateRequest(token AccessTokenCreateRequest) error {
+	if token.Description == "" {
+		return fmt.Errorf("access token description cannot be empty")
+	}
+	if token.Type != AccessModeRead && token.Type != AccessModeWrite && token.Type != AccessModeReadWrite {
+		return fmt.Errorf("invalid access token type: %s", token.Type)
+	}
+	if token.TenantID != "" {
+		if _, err := ParseTenantID(token.TenantID); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-var tenantIDRegex = regexp.MustCompile(`^(\d+)(:\d+)?$`)
+// checkPathParam checks the user-supplied parameter used as a path segment of the API request.
+// The segment is escaped by requestAPI, so only values changing the meaning of the path are rejected.
+func checkPathParam(field, value string) error {
+	switch value {
+	case "":
+		return &ValidationError{Field: field, Value: value, Reason: "cannot be empty"}
+	case ".", "..":
+		return &ValidationError{Field: field, Value: value, Reason: "cannot be a relative path"}
+	}
+	return nil
+}
 
-func isValidTenantID(tenantID string) bool {
-	return tenantIDRegex.MatchString(tenantID)
+// ValidateRuleFileName checks that the name can be used for a new alerting/recording rules file:
+// it must contain only latin letters, digits, dots, underscores and hyphens, have .yml or .yaml extension
+// and be not longer than MaxRuleFileNameLength. It returns *ValidationError if the name is invalid.
+func ValidateRuleFileName(name string) error {
+	if err := checkPathParam("rule file name", name); err != nil {
+		return err
+	}
+	if len(name) > MaxRuleFileNameLength {
+		return &ValidationError{Field: "rule file name", Value: name, Reason: fmt.Sprintf("must not be longer than %d characters", MaxRuleFileNameLength)}
+	}
+	if !ruleFileNameRegex.MatchString(name) {
+		return &ValidationError{Field: "rule file name", Value: name, Reason: "must contain only latin letters, digits, dots, underscores and hyphens"}
+	}
+	if ext := path.Ext(name); ext != ".yml" && ext != ".yaml" || len(name) == len(ext) {
+		return &ValidationError{Field: "rule file name", Value: name, Reason: "must have .yml or .yaml extension"}
+	}
+	return nil
 }
 
 // validateCommonDeploymentParams validates parameters common to both create and update operations
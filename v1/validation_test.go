@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -262,3 +263,37 @@ func TestValidateCreateDeploymentParams(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRuleFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		wantErr  bool
+	}{
+		{name: "valid yml", fileName: "alerts.yml"},
+		{name: "valid yaml with dashes", fileName: "team-a_rules.v2.yaml"},
+		{name: "empty", fileName: "", wantErr: true},
+		{name: "parent directory", fileName: "..", wantErr: true},
+		{name: "slash", fileName: "team/alerts.yml", wantErr: true},
+		{name: "space", fileName: "my alerts.yml", wantErr: true},
+		{name: "query characters", fileName: "alerts.yml?x=1", wantErr: true},
+		{name: "no extension", fileName: "alerts", wantErr: true},
+		{name: "wrong extension", fileName: "alerts.json", wantErr: true},
+		{name: "extension only", fileName: ".yml", wantErr: true},
+		{name: "too long", fileName: strings.Repeat("a", MaxRuleFileNameLength-3) + ".yml", wantErr: true},
+		{name: "max length", fileName: strings.Repeat("a", MaxRuleFileNameLength-4) + ".yml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRuleFileName(tt.fileName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRuleFileName(%q) error = %v, wantErr %v", tt.fileName, err, tt.wantErr)
+			}
+			var validationErr *ValidationError
+			if err != nil && !errors.As(err, &validationErr) {
+				t.Errorf("ValidateRuleFileName(%q) error type = %T, want *ValidationError", tt.fileName, err)
+			}
+		})
+	}
+}